@@ -1,25 +1,184 @@
 package main
 
 import (
-	"database/sql"
 	"eventTracker/cmd/server"
+	"eventTracker/internal/alerting"
+	"eventTracker/internal/auth"
 	"eventTracker/internal/db"
 	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"eventTracker/internal/ratelimit"
+	"eventTracker/internal/statsd"
+	"flag"
 	"fmt"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	"os"
+	"time"
+	_ "time/tzdata"
 )
 
 func main() {
-	database, err := sql.Open("sqlite3", "./db.db")
+	storage := flag.String("storage", db.StorageSQLite, "storage backend: sqlite, postgres or memory")
+	dsn := flag.String("dsn", "", "data source name of the storage backend (defaults to ./db.db for sqlite)")
+	seed := flag.Bool("seed", false, "load the debug start point data into the memory backend")
+	migrate := flag.Bool("migrate", true, "apply the pending schema migrations on startup")
+	retentionInterval := flag.Duration("retention-interval", time.Hour, "how often the expired occurrences are pruned (0 disables pruning)")
+	retentionBatch := flag.Int("retention-batch", 1000, "maximum number of daily rows deleted per pruning transaction")
+	idempotencyWindow := flag.Duration("idempotency-window", server.DefaultIdempotencyWindow, "how long the idempotency keys of the created events are remembered")
+	adminKey := flag.String("admin-key", os.Getenv("EVENT_TRACKER_ADMIN_KEY"), "admin API key stored on startup if missing, to create the other keys (defaults to $EVENT_TRACKER_ADMIN_KEY)")
+	rateLimit := flag.Float64("rate-limit", 50, "default number of requests per second of each API key (0 disables rate limiting)")
+	rateBurst := flag.Uint64("rate-burst", 100, "default number of requests each API key can make at once above its rate limit")
+	dailyQuota := flag.Uint64("daily-quota", 0, "default number of occurrences each API key can record per day (0 means no quota)")
+	statsdAddr := flag.String("statsd-addr", "", "UDP address of the StatsD counters listener, e.g. :8125 (disabled when empty)")
+	statsdFlushInterval := flag.Duration("statsd-flush-interval", 10*time.Second, "how often the StatsD counters are recorded")
+	statsdProject := flag.String("statsd-project", db.DefaultProject, "project in which the StatsD counters are recorded")
+	streamBuffer := flag.Int("stream-buffer", 256, "number of occurrences buffered for each client of the live stream before dropping them (0 disables the stream)")
+	alertInterval := flag.Duration("alert-interval", time.Minute, "how often the alert rules are evaluated (0 disables the evaluation)")
+	alertWorkers := flag.Int("alert-workers", 4, "number of the alert notifications delivered at once")
+	alertQueue := flag.Int("alert-queue", 256, "number of the alert notifications waiting for delivery before dropping them")
+	insecureWebhooks := flag.Bool("alert-insecure-webhooks", false, "let the alert rules notify http webhooks and private, loopback or link-local addresses")
+	flag.Parse()
+
+	if *rateLimit < 0 {
+		panic("the rate limit must not be negative")
+	}
+
+	database, err := db.OpenStorage(*storage, *dsn, *seed)
 	if err != nil {
 		panic(fmt.Sprintf("error loading the database: %s", err.Error()))
 	}
 
+	if flag.Arg(0) == "migrate" {
+		err = runMigrateCommand(database, flag.Arg(1))
+		if err != nil {
+			println(fmt.Sprintf("error: %v", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *migrate {
+		_, err = database.MigrateUp()
+		if err != nil {
+			panic(fmt.Sprintf("error migrating the database: %s", err.Error()))
+		}
+	}
+
+	var broker *event.Broker
+	if *streamBuffer > 0 {
+		broker = event.NewBroker(*streamBuffer)
+		database.EventIngestHandler = event.PublishingIngestHandler{EventIngestHandler: database.EventIngestHandler, Broker: broker, Project: db.DefaultProject}
+	}
+
+	keyService := auth.KeyService{}
+	if *adminKey != "" {
+		err = keyService.EnsureAPIKey(database.APIKeyDBHandler, "admin", *adminKey, []string{auth.ScopeAdmin}, time.Now())
+		if err != nil {
+			panic(fmt.Sprintf("error storing the admin key: %s", err.Error()))
+		}
+	}
+
+	alertNotifier := alerting.NewWebhookNotifier(*insecureWebhooks)
+
 	env := server.Env{
 		EventService: event.EventService{},
-		EventDBHandler: db.EventDB{Database: database},
-		EventFreqDBHandler: db.EventFreqDB{Database: database},
+		EventDBHandler: database.EventDBHandler,
+		EventFreqDBHandler: database.EventFreqDBHandler,
+		EventIngestHandler: database.EventIngestHandler,
+		EventPropertyDBHandler: database.EventPropertyDBHandler,
+		EventOccurrenceDBHandler: database.EventOccurrenceDBHandler,
+		EventRetentionDBHandler: database.EventRetentionDBHandler,
+		EventRollupDBHandler: database.EventRollupDBHandler,
+		EventUniqueDBHandler: database.EventUniqueDBHandler,
+		EventValueDBHandler: database.EventValueDBHandler,
+		IdempotencyWindow: *idempotencyWindow,
+		Broker: broker,
+		KeyService: keyService,
+		APIKeyDBHandler: database.APIKeyDBHandler,
+		AlertRuleDBHandler: database.AlertRuleDBHandler,
+		AlertNotifier: alertNotifier,
+		AllowInsecureWebhooks: *insecureWebhooks,
+		Limiter: ratelimit.NewLimiter(model.APIKeyLimits{RateLimit: *rateLimit, Burst: *rateBurst, DailyQuota: *dailyQuota}),
+	}
+
+	if *retentionInterval > 0 {
+		if *retentionBatch < 1 {
+			panic("the retention batch must be of at least 1 daily row")
+		}
+
+		go env.EventService.RunJanitor(env.EventFreqDBHandler, env.EventRetentionDBHandler, *retentionInterval, *retentionBatch, nil)
+	}
+
+	if *alertInterval > 0 {
+		if *alertWorkers < 1 || *alertQueue < 1 {
+			panic("the alert notifications need at least 1 worker and a queue of 1")
+		}
+
+		dispatcher := event.NewAlertDispatcher(alertNotifier, *alertWorkers, *alertQueue)
+		go env.EventService.RunAlertRules(env.EventOccurrenceDBHandler, env.AlertRuleDBHandler, dispatcher, *alertInterval, nil)
+	}
+
+	if *statsdAddr != "" {
+		err = auth.ValidateProject(*statsdProject)
+		if err != nil {
+			panic(err.Error())
+		}
+		if *statsdFlushInterval <= 0 {
+			panic("the StatsD flush interval must be positive")
+		}
+
+		listener := statsd.NewListener(env.EventService, env.EventIngestHandler.ForProject(*statsdProject), *statsdFlushInterval)
+		go func() {
+			err := listener.ListenAndServe(*statsdAddr, nil)
+			if err != nil {
+				panic(fmt.Sprintf("error listening for StatsD counters: %s", err.Error()))
+			}
+		}()
 	}
 
 	server.HandleRequests(env)
-}
\ No newline at end of file
+}
+
+// runMigrateCommand handles "migrate up", "migrate down" and "migrate status".
+func runMigrateCommand(database db.Storage, command string) error {
+	switch command {
+	case "up":
+		applied, err := database.MigrateUp()
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if applied == nil {
+			fmt.Println("nothing to apply")
+		}
+	case "down":
+		reverted, ok, err := database.MigrateDown()
+		if err != nil {
+			return err
+		}
+		if ok {
+			fmt.Printf("reverted %04d_%s\n", reverted.Version, reverted.Name)
+		} else {
+			fmt.Println("nothing to revert")
+		}
+	case "status":
+		status, err := database.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, m := range status {
+			state := "pending"
+			if m.Applied {
+				state = "applied " + m.AppliedAt
+			}
+			fmt.Printf("%04d_%s\t%s\n", m.Version, m.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/alerting"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const maxAlertWindowMinutes = 31 * 24 * 60
+
+func (env Env) ReturnAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := env.EventService.AlertRules(env.AlertRuleDBHandler)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(rules)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) ReturnAlertRule(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseAlertRuleID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := env.EventService.AlertRule(env.AlertRuleDBHandler, ID)
+	if errors.Is(err, model.ErrAlertRuleNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), ID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(rule)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	body, ok := env.decodeAlertRuleBody(w, r)
+	if !ok {
+		return
+	}
+
+	createdRule, err := env.EventService.CreateAlertRule(env.AlertRuleDBHandler, body, time.Now())
+	if errors.Is(err, model.ErrAlertRuleExists) {
+		http.Error(w, fmt.Sprintf(err.Error(), body.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(createdRule)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// UpdateAlertRule keeps the secret when the body leaves it out, and removes it when it is empty.
+func (env Env) UpdateAlertRule(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseAlertRuleID(w, r)
+	if !ok {
+		return
+	}
+
+	body, ok := env.decodeAlertRuleBody(w, r)
+	if !ok {
+		return
+	}
+
+	updatedRule, err := env.EventService.UpdateAlertRule(env.AlertRuleDBHandler, ID, body)
+	if errors.Is(err, model.ErrAlertRuleNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), ID), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, model.ErrAlertRuleExists) {
+		http.Error(w, fmt.Sprintf(err.Error(), body.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(updatedRule)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseAlertRuleID(w, r)
+	if !ok {
+		return
+	}
+
+	err := env.EventService.DeleteAlertRule(env.AlertRuleDBHandler, ID)
+	if errors.Is(err, model.ErrAlertRuleNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), ID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (env Env) TestAlertRule(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseAlertRuleID(w, r)
+	if !ok {
+		return
+	}
+
+	if env.AlertNotifier == nil {
+		http.Error(w, "The alert notifications are disabled", http.StatusNotFound)
+		return
+	}
+
+	rule, err := env.EventService.AlertRule(env.AlertRuleDBHandler, ID)
+	if errors.Is(err, model.ErrAlertRuleNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), ID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = env.AlertNotifier.Notify(rule, event.NewAlertNotification(rule, event.AlertTest, time.Now()))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(model.ErrNotifyWebhook.Error(), ID, err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (env Env) decodeAlertRuleBody(w http.ResponseWriter, r *http.Request) (model.AlertRuleBody, bool) {
+	var body model.AlertRuleBody
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Json decoder error: %s", err.Error()), http.StatusBadRequest)
+		return model.AlertRuleBody{}, false
+	}
+
+	err = validateAlertRule(body, env.AllowInsecureWebhooks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return model.AlertRuleBody{}, false
+	}
+
+	return body, true
+}
+
+func validateAlertRule(body model.AlertRuleBody, insecureWebhooks bool) error {
+	if body.Name == "" {
+		return errors.New("The \"name\" of the rule is required")
+	}
+	if body.Event == "" {
+		return errors.New("The \"event\" of the rule is required")
+	}
+
+	switch body.Condition {
+	case event.AlertAbove:
+	case event.AlertBelow:
+		if body.Threshold == 0 {
+			return errors.New("The \"threshold\" of a \"below\" rule must be at least 1, which fires when there are no occurrences")
+		}
+	default:
+		return fmt.Errorf("Invalid \"condition\" %q, must be %q or %q", body.Condition, event.AlertAbove, event.AlertBelow)
+	}
+
+	if body.WindowMinutes < 1 || body.WindowMinutes > maxAlertWindowMinutes {
+		return fmt.Errorf("The \"window_minutes\" of the rule must be from 1 to %d", maxAlertWindowMinutes)
+	}
+
+	return alerting.ValidateWebhookURL(body.WebhookURL, insecureWebhooks)
+}
+
+func parseAlertRuleID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	params := mux.Vars(r)
+
+	ID, err := strconv.ParseUint(params["id"], 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing the alert rule id: %s", err.Error()), http.StatusBadRequest)
+		return 0, false
+	}
+
+	return ID, true
+}
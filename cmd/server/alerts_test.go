@@ -0,0 +1,43 @@
+package server
+
+import (
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"testing"
+)
+
+func TestValidateAlertRule(t *testing.T) {
+	valid := model.AlertRuleBody{Name: "no checkouts", Event: "checkout", Condition: event.AlertBelow, Threshold: 1, WindowMinutes: 1440, WebhookURL: "https://hooks.example.com/alerts"}
+	if err := validateAlertRule(valid, false); err != nil {
+		t.Fatalf("the rule %+v is invalid: %v", valid, err)
+	}
+
+	for name, change := range map[string]func(body *model.AlertRuleBody){
+		"below 0":           func(body *model.AlertRuleBody) { body.Threshold = 0 },
+		"unknown condition": func(body *model.AlertRuleBody) { body.Condition = "equal" },
+		"empty window":      func(body *model.AlertRuleBody) { body.WindowMinutes = 0 },
+		"ftp webhook":       func(body *model.AlertRuleBody) { body.WebhookURL = "ftp://hooks.example.com" },
+		"http webhook":      func(body *model.AlertRuleBody) { body.WebhookURL = "http://hooks.example.com/alerts" },
+		"loopback webhook":  func(body *model.AlertRuleBody) { body.WebhookURL = "https://127.0.0.1/alerts" },
+		"private webhook":   func(body *model.AlertRuleBody) { body.WebhookURL = "https://10.1.2.3/alerts" },
+		"metadata webhook":  func(body *model.AlertRuleBody) { body.WebhookURL = "https://169.254.169.254/latest" },
+	} {
+		body := valid
+		change(&body)
+		if err := validateAlertRule(body, false); err == nil {
+			t.Fatalf("the rule with %s is valid", name)
+		}
+	}
+
+	above := valid
+	above.Condition, above.Threshold = event.AlertAbove, 0
+	if err := validateAlertRule(above, false); err != nil {
+		t.Fatalf("an above rule with a threshold of 0 is invalid: %v", err)
+	}
+
+	local := valid
+	local.WebhookURL = "http://127.0.0.1:8080/alerts"
+	if err := validateAlertRule(local, true); err != nil {
+		t.Fatalf("a local webhook is invalid with the insecure webhooks: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"github.com/gorilla/mux"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAnomalyHours     = 24
+	maxAnomalyHours         = 7 * 24
+	defaultAnomalyWeeks     = 4
+	minAnomalyWeeks         = 2
+	maxAnomalyWeeks         = 12
+	defaultAnomalyThreshold = 3
+)
+
+func (env Env) ReturnEventAnomalies(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	name := params["name"]
+	queryParams := r.URL.Query()
+
+	location, err := parseLocation(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	end := time.Now().In(location)
+	if value := queryParams.Get("end"); value != "" {
+		end, err = parseTimeParam(value, location)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error parsing \"end\" query parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	hours, err := parseIntParam(queryParams.Get("hours"), defaultAnomalyHours, 1, maxAnomalyHours)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing \"hours\" query parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+	weeks, err := parseIntParam(queryParams.Get("weeks"), defaultAnomalyWeeks, minAnomalyWeeks, maxAnomalyWeeks)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing \"weeks\" query parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	threshold := float64(defaultAnomalyThreshold)
+	if value := queryParams.Get("threshold"); value != "" {
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || threshold <= 0 || math.IsInf(threshold, 0) {
+			http.Error(w, fmt.Sprintf("Error parsing \"threshold\" query parameter: %q must be a positive number", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// An event without occurrences in the hours checked has no anomalies, but one never recorded is not found.
+	err = env.EventService.EventExists(env.EventFreqDBHandler, name)
+	if errors.Is(err, model.ErrEventNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), name), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	anomalies, err := env.EventService.EventAnomalies(env.EventOccurrenceDBHandler, name, end, hours, weeks, threshold)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(anomalies)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func parseIntParam(value string, defaultValue, minValue, maxValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < minValue || parsed > maxValue {
+		return 0, fmt.Errorf("%q must be an integer from %d to %d", value, minValue, maxValue)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReturnEventAnomaliesUnknownEvent(t *testing.T) {
+	for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			env, storage := newTestEnv(t, backend)
+
+			date := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+			if err := env.EventService.CreateEvent(storage.EventIngestHandler, "login", 3, date, nil, "", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			router := newTestRouter(env, http.MethodGet, "/admin/v1/events/{name}/anomalies", Env.ReturnEventAnomalies)
+			anomalies := func(name string) *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/admin/v1/events/"+name+"/anomalies?end=2021-01-02", nil)
+				r.Header.Set("x-api-key", testAPIKey)
+				router.ServeHTTP(w, r)
+				return w
+			}
+
+			if w := anomalies("login"); w.Code != http.StatusOK {
+				t.Fatalf("a recorded event got status %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+
+			w := anomalies("logout")
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("an unknown event got status %d, want %d: %s", w.Code, http.StatusNotFound, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), "event logout not found") {
+				t.Fatalf("an unknown event got %q, want the event not found", w.Body.String())
+			}
+
+			// An error of the storage isn't taken for an unknown event.
+			env.EventFreqDBHandler = failingEventFreqDB{EventFreqDBHandler: env.EventFreqDBHandler}
+			router = newTestRouter(env, http.MethodGet, "/admin/v1/events/{name}/anomalies", Env.ReturnEventAnomalies)
+			if w = anomalies("login"); w.Code != http.StatusInternalServerError {
+				t.Fatalf("a failing storage got status %d, want %d: %s", w.Code, http.StatusInternalServerError, w.Body.String())
+			}
+		})
+	}
+}
+
+// failingEventFreqDB fails to look up the events by name.
+type failingEventFreqDB struct {
+	db.EventFreqDBHandler
+}
+
+func (failingEventFreqDB) GetEventByName(name string) (model.EventFreq, error) {
+	return model.EventFreq{}, errors.New("the database is unreachable")
+}
+
+func (f failingEventFreqDB) ForProject(project string) db.EventFreqDBHandler {
+	return f
+}
@@ -1,8 +1,13 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"eventTracker/internal/event"
 	"eventTracker/internal/model"
 	"eventTracker/internal/plotting"
 	"fmt"
@@ -10,34 +15,90 @@ import (
 	"gonum.org/v1/plot/plotter"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
 func (env Env) ReturnEvents(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
-	println(fmt.Sprintf("query: %v", queryParams))
 
 	var (
 		retrievedEvents []model.Event
 		err error
 	)
-	if len(queryParams) == 1 {
+	startDate, endDate := queryParams.Get("start_date"), queryParams.Get("end_date")
+	if (startDate == "") != (endDate == "") {
 		http.Error(w, "Only one query parameter is not allowed. Both \"start_date\" and \"end_date\" must be present", http.StatusBadRequest)
 		return
-	} else if len(queryParams) > 0 {
-		_, e := time.Parse("2006-01-02", queryParams["start_date"][0])
+	} else if startDate != "" {
+		_, e := time.Parse("2006-01-02", startDate)
 		if e != nil {
 			http.Error(w, fmt.Sprintf("Error parsing \"start_date\" query parameter: %s", e), http.StatusBadRequest)
 			return
 		}
-		_, e = time.Parse("2006-01-02", queryParams["end_date"][0])
+		_, e = time.Parse("2006-01-02", endDate)
 		if e != nil {
 			http.Error(w, fmt.Sprintf("Error parsing \"end_date\" query parameter: %s", e), http.StatusBadRequest)
 			return
 		}
+	}
+
+	propertyQuery, err := parsePropertyQuery(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	location, err := parseLocation(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The rollups are in UTC, so the weeks, months and years can't be combined with properties or other time zones.
+	interval := queryParams.Get("interval")
+	if interval == "" {
+		interval = event.IntervalDay
+	}
+	if interval != event.IntervalDay && interval != event.IntervalWeek && interval != event.IntervalMonth && interval != event.IntervalYear {
+		http.Error(w, fmt.Sprintf("Invalid \"interval\" query parameter %s, must be day, week, month or year", interval), http.StatusBadRequest)
+		return
+	}
+	if interval != event.IntervalDay && (!propertyQuery.IsEmpty() || location != time.UTC) {
+		http.Error(w, "The \"interval\" query parameter can't be combined with properties or time zones", http.StatusBadRequest)
+		return
+	}
 
-		retrievedEvents, err = env.EventService.EventsByDateRange(env.EventDBHandler, queryParams["start_date"][0], queryParams["end_date"][0])
+	// The unique users are kept by UTC day, without their properties.
+	withUniques := queryParams.Get("uniques") == "true"
+	if withUniques && (!propertyQuery.IsEmpty() || location != time.UTC) {
+		http.Error(w, "The \"uniques\" query parameter can't be combined with properties or time zones", http.StatusBadRequest)
+		return
+	}
+
+	if interval != event.IntervalDay {
+		retrievedEvents, err = env.EventService.EventsByInterval(env.EventRollupDBHandler, "", startDate, endDate, interval)
+		if errors.Is(err, model.ErrInvalidRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	} else if !propertyQuery.IsEmpty() {
+		retrievedEvents, err = env.EventService.EventsByProperties(env.EventPropertyDBHandler, propertyQuery, startDate, endDate, location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	} else if location != time.UTC {
+		retrievedEvents, err = env.EventService.EventsInLocation(env.EventOccurrenceDBHandler, "", startDate, endDate, location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	} else if startDate != "" {
+		retrievedEvents, err = env.EventService.EventsByDateRange(env.EventDBHandler, startDate, endDate)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
@@ -48,6 +109,14 @@ func (env Env) ReturnEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if withUniques {
+		err = env.EventService.AddEventUniques(env.EventUniqueDBHandler, retrievedEvents, interval)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	err = json.NewEncoder(w).Encode(retrievedEvents)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -59,7 +128,18 @@ func (env Env) ReturnEvent(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	name := params["name"]
 
-	retrievedEvent, err := env.EventService.EventsByName(env.EventDBHandler, name)
+	location, err := parseLocation(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var retrievedEvent []model.Event
+	if location != time.UTC {
+		retrievedEvent, err = env.EventService.EventsInLocation(env.EventOccurrenceDBHandler, name, "", "", location)
+	} else {
+		retrievedEvent, err = env.EventService.EventsByName(env.EventDBHandler, name)
+	}
 	if errors.Is(err, model.ErrEventNotFound) {
 		http.Error(w, fmt.Sprintf(err.Error(), name), http.StatusNotFound)
 		return
@@ -88,22 +168,63 @@ func (env Env) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var parsedDate time.Time
-	if body.Date == "" {
-		parsedDate = time.Now()
-	} else {
-		parsedDate, err = time.Parse("2006-01-02 15:04:05", body.Date)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Error trying to decode date: %s", err.Error()), http.StatusBadRequest)
-			return
-		}
+	parsedDate, err := parseEventDate(body.Date)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error trying to decode date: %s", err.Error()), http.StatusBadRequest)
+		return
 	}
 
 	if body.Count == 0 {
 		body.Count = 1
 	}
 
-	err = env.EventService.CreateEvent(env.EventDBHandler, env.EventFreqDBHandler, name, body.Count, parsedDate)
+	err = validateProperties(body.Properties)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateUserID(body.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Retries carry the same idempotency key, either in the header or in the body, and are only recorded once.
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if idempotencyKey == "" {
+		idempotencyKey = body.ID
+	} else if body.ID != "" && body.ID != idempotencyKey {
+		http.Error(w, "The \"Idempotency-Key\" header and the \"id\" field must match", http.StatusBadRequest)
+		return
+	}
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		http.Error(w, fmt.Sprintf("The idempotency key must have at most %d characters", maxIdempotencyKeyLength), http.StatusBadRequest)
+		return
+	}
+
+	if !env.reserveIngestion(w, r, body.Count) {
+		return
+	}
+
+	if idempotencyKey == "" {
+		err = env.EventService.CreateEvent(env.EventIngestHandler, name, body.Count, parsedDate, body.Properties, body.UserID, body.Value)
+	} else {
+		key := model.IdempotencyKey{Key: idempotencyKey, RequestHash: eventRequestHash(name, body), CreatedAt: time.Now().UTC()}
+		err = env.EventService.CreateEventOnce(env.EventIngestHandler, key, env.idempotencyWindow(), name, body.Count, parsedDate, body.Properties, body.UserID, body.Value)
+	}
+	if err != nil {
+		env.releaseIngestion(r, body.Count)
+	}
+	if errors.Is(err, model.ErrDuplicateRequest) {
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	if errors.Is(err, model.ErrIdempotencyKeyReused) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -112,11 +233,164 @@ func (env Env) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+const maxIdempotencyKeyLength = 255
+
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+func (env Env) idempotencyWindow() time.Duration {
+	if env.IdempotencyWindow <= 0 {
+		return DefaultIdempotencyWindow
+	}
+
+	return env.IdempotencyWindow
+}
+
+// The date is hashed as it was sent, so the retries of a request without a date have the same hash.
+func eventRequestHash(name string, body model.EventBody) string {
+	body.ID = ""
+	bodyBytes, _ := json.Marshal(body)
+
+	hash := sha256.Sum256(append([]byte(name+"\x00"), bodyBytes...))
+	return hex.EncodeToString(hash[:])
+}
+
+func (env Env) CreateEventsBatch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBytes)
+
+	rawItems, err := readBatchItems(r)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("The batch is larger than %d bytes", maxBatchBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Is(err, model.ErrBatchTooLarge) {
+		http.Error(w, fmt.Sprintf(err.Error(), maxBatchItems), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading the batch: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if len(rawItems) == 0 {
+		http.Error(w, "The batch is empty", http.StatusBadRequest)
+		return
+	}
+
+	report := model.EventBatchReport{Results: make([]model.EventBatchResult, len(rawItems))}
+	var (
+		occurrences  []model.EventOccurrence
+		validIndexes []int
+	)
+	for i, rawItem := range rawItems {
+		occurrence, e := parseBatchItem(rawItem)
+		report.Results[i] = model.EventBatchResult{Index: i, Name: occurrence.Name}
+		if e != nil {
+			report.Results[i].Status = "invalid"
+			report.Results[i].Error = e.Error()
+			report.Failed++
+			continue
+		}
+
+		occurrences = append(occurrences, occurrence)
+		validIndexes = append(validIndexes, i)
+	}
+
+	status := http.StatusCreated
+	if len(occurrences) == 0 {
+		status = http.StatusBadRequest
+	} else {
+		var count uint64
+		for _, occurrence := range occurrences {
+			count += occurrence.Count
+		}
+		if !env.reserveIngestion(w, r, count) {
+			return
+		}
+
+		err = env.EventService.CreateEvents(env.EventIngestHandler, occurrences)
+		if err != nil {
+			env.releaseIngestion(r, count)
+		}
+		for _, i := range validIndexes {
+			if err != nil {
+				report.Results[i].Status = "failed"
+				report.Results[i].Error = err.Error()
+				report.Failed++
+			} else {
+				report.Results[i].Status = "created"
+				report.Created++
+			}
+		}
+
+		if err != nil {
+			status = http.StatusInternalServerError
+		} else if report.Failed > 0 {
+			status = http.StatusMultiStatus
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		println(fmt.Sprintf("error: %v", err.Error()))
+		return
+	}
+}
+
+func (env Env) ReturnEventSeries(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	name := params["name"]
+	queryParams := r.URL.Query()
+
+	location, err := parseLocation(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start, err := parseTimeParam(queryParams.Get("start"), location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing \"start\" query parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+	end, err := parseTimeParam(queryParams.Get("end"), location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing \"end\" query parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	interval := queryParams.Get("interval")
+	if interval == "" {
+		interval = event.IntervalDay
+	}
+	if !event.ValidInterval(interval) {
+		http.Error(w, fmt.Sprintf(model.ErrInvalidInterval.Error(), interval), http.StatusBadRequest)
+		return
+	}
+
+	series, err := env.EventService.EventSeries(env.EventOccurrenceDBHandler, env.EventRollupDBHandler, name, start, end, interval)
+	if errors.Is(err, model.ErrInvalidRange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(series)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 func (env Env) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	name := params["name"]
 
-	err := env.EventService.DeleteEvent(env.EventDBHandler, env.EventFreqDBHandler, name)
+	err := env.EventService.DeleteEvent(env.EventDBHandler, env.EventFreqDBHandler, env.EventPropertyDBHandler, env.EventOccurrenceDBHandler, env.EventRollupDBHandler, env.EventUniqueDBHandler, env.EventValueDBHandler, name)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -135,7 +409,24 @@ func (env Env) ReturnEventFrequency(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	name := params["name"]
 
-	retrievedEvent, err := env.EventService.EventFrequencyByName(env.EventFreqDBHandler, name)
+	propertyQuery, err := parsePropertyQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	location, err := parseLocation(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var retrievedEvent interface{}
+	if !propertyQuery.IsEmpty() {
+		retrievedEvent, err = env.EventService.EventFrequenciesByProperties(env.EventPropertyDBHandler, name, propertyQuery, location)
+	} else {
+		retrievedEvent, err = env.eventFrequency(name, location)
+	}
 	if errors.Is(err, model.ErrEventNotFound) {
 		http.Error(w, fmt.Sprintf(err.Error(), name), http.StatusNotFound)
 		return
@@ -153,7 +444,26 @@ func (env Env) ReturnEventFrequency(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env Env) ReturnAllEventsFrequencies(w http.ResponseWriter, r *http.Request) {
-	retrievedEvents, err := env.EventService.AllEventsFrequencies(env.EventFreqDBHandler)
+	propertyQuery, err := parsePropertyQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	location, err := parseLocation(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var retrievedEvents []model.EventFreq
+	if !propertyQuery.IsEmpty() {
+		retrievedEvents, err = env.EventService.EventFrequenciesByProperties(env.EventPropertyDBHandler, "", propertyQuery, location)
+	} else if location != time.UTC {
+		retrievedEvents, err = env.EventService.EventFrequenciesInLocation(env.EventOccurrenceDBHandler, "", location)
+	} else {
+		retrievedEvents, err = env.EventService.AllEventsFrequencies(env.EventFreqDBHandler)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -179,23 +489,15 @@ func (env Env) ReturnAllEventsHistory(w http.ResponseWriter, r *http.Request) {
 }
 
 func (env Env) ReturnEventFrequencyHistogram(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	name := params["name"]
-
-	retrievedEvent, err := env.EventService.EventFrequencyByName(env.EventFreqDBHandler, name)
-	if errors.Is(err, model.ErrEventNotFound) {
-		http.Error(w, fmt.Sprintf(err.Error(), name), http.StatusNotFound)
-		return
-	}
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	retrievedEvent, ok := env.plottedEventFrequency(w, r)
+	if !ok {
 		return
 	}
 
 	//var histImage *image.Image
 	var values plotter.Values
 	for _, h := range retrievedEvent.HourCount {
-		values = append(values, 100 * float64(h) / float64(retrievedEvent.TotalCount))
+		values = append(values, percentOf(h, retrievedEvent.TotalCount))
 	}
 	writer := plotting.PlotHistogram(values, retrievedEvent.Name)
 
@@ -207,4 +509,283 @@ func (env Env) ReturnEventFrequencyHistogram(w http.ResponseWriter, r *http.Requ
 	}
 	w.Header().Set("Content-Type", "image/jpeg")
 	w.Header().Set("Content-Length", strconv.Itoa(int(imgBytes)))
-}
\ No newline at end of file
+}
+
+func (env Env) ReturnEventFrequencyHeatmap(w http.ResponseWriter, r *http.Request) {
+	retrievedEvent, ok := env.plottedEventFrequency(w, r)
+	if !ok {
+		return
+	}
+
+	var values [7][24]float64
+	for weekday, hourCount := range retrievedEvent.WeekdayHourCount {
+		for hour, count := range hourCount {
+			values[weekday][hour] = percentOf(count, retrievedEvent.TotalCount)
+		}
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	_, err := plotting.PlotHeatmap(values, retrievedEvent.Name).WriteTo(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func percentOf(count, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return 100 * float64(count) / float64(total)
+}
+
+// The plots show a single distribution, so the occurrences can be filtered by their properties but not grouped.
+func (env Env) plottedEventFrequency(w http.ResponseWriter, r *http.Request) (model.EventFreq, bool) {
+	params := mux.Vars(r)
+	name := params["name"]
+
+	propertyQuery, err := parsePropertyQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return model.EventFreq{}, false
+	}
+	if len(propertyQuery.GroupBy) > 0 {
+		http.Error(w, "The \"group_by\" query parameter is not allowed for plots", http.StatusBadRequest)
+		return model.EventFreq{}, false
+	}
+
+	location, err := parseLocation(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return model.EventFreq{}, false
+	}
+
+	var retrievedEvent model.EventFreq
+	if propertyQuery.IsEmpty() {
+		retrievedEvent, err = env.eventFrequency(name, location)
+	} else {
+		var retrievedEvents []model.EventFreq
+		retrievedEvents, err = env.EventService.EventFrequenciesByProperties(env.EventPropertyDBHandler, name, propertyQuery, location)
+		if err == nil && len(retrievedEvents) == 0 {
+			err = model.ErrEventNotFound
+		}
+		if err == nil {
+			retrievedEvent = retrievedEvents[0]
+		}
+	}
+	if errors.Is(err, model.ErrEventNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), name), http.StatusNotFound)
+		return model.EventFreq{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return model.EventFreq{}, false
+	}
+
+	return retrievedEvent, true
+}
+
+const maxBatchItems = 10000
+
+const maxBatchBytes = 16 << 20
+
+// The dates without an offset are read as UTC.
+func parseEventDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Now().UTC(), nil
+	}
+
+	parsedDate, err := time.Parse(time.RFC3339, date)
+	if err == nil {
+		return parsedDate.UTC(), nil
+	}
+
+	return time.Parse("2006-01-02 15:04:05", date)
+}
+
+func parseLocation(queryParams url.Values) (*time.Location, error) {
+	tz := queryParams.Get("tz")
+	if tz == "" || tz == "UTC" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing \"tz\" query parameter: %s", err)
+	}
+
+	return location, nil
+}
+
+func (env Env) eventFrequency(name string, location *time.Location) (model.EventFreq, error) {
+	if location == time.UTC {
+		return env.EventService.EventFrequencyByName(env.EventFreqDBHandler, name)
+	}
+
+	retrievedEvents, err := env.EventService.EventFrequenciesInLocation(env.EventOccurrenceDBHandler, name, location)
+	if err != nil {
+		return model.EventFreq{}, err
+	}
+
+	return retrievedEvents[0], nil
+}
+
+func readBatchItems(r *http.Request) (rawItems [][]byte, err error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-ndjson") {
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			if len(rawItems) == maxBatchItems {
+				return nil, model.ErrBatchTooLarge
+			}
+			rawItems = append(rawItems, append([]byte(nil), line...))
+		}
+
+		return rawItems, scanner.Err()
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("the batch must be a JSON array")
+	}
+
+	for decoder.More() {
+		if len(rawItems) == maxBatchItems {
+			return nil, model.ErrBatchTooLarge
+		}
+
+		var message json.RawMessage
+		err = decoder.Decode(&message)
+		if err != nil {
+			return nil, err
+		}
+		rawItems = append(rawItems, message)
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return rawItems, nil
+}
+
+func parseBatchItem(rawItem []byte) (occurrence model.EventOccurrence, err error) {
+	var item model.EventBatchItem
+
+	err = json.Unmarshal(rawItem, &item)
+	if err != nil {
+		return model.EventOccurrence{}, fmt.Errorf("json decoder error: %s", err.Error())
+	}
+
+	occurrence.Name = item.Name
+	if item.Name == "" {
+		return occurrence, errors.New("missing \"event\" name")
+	}
+
+	occurrence.Date, err = parseEventDate(item.Date)
+	if err != nil {
+		return occurrence, fmt.Errorf("error trying to decode date: %s", err.Error())
+	}
+
+	occurrence.Count = item.Count
+	if occurrence.Count == 0 {
+		occurrence.Count = 1
+	}
+
+	err = validateProperties(item.Properties)
+	if err != nil {
+		return occurrence, err
+	}
+	occurrence.Properties = item.Properties
+
+	err = validateUserID(item.UserID)
+	if err != nil {
+		return occurrence, err
+	}
+	occurrence.UserID = item.UserID
+	occurrence.Value = item.Value
+
+	return occurrence, nil
+}
+
+const maxEventProperties = 20
+
+// The names can't contain ":" nor ",", which separate them in the "where" and "group_by" query parameters.
+func validateProperties(properties map[string]string) error {
+	if len(properties) > maxEventProperties {
+		return fmt.Errorf("an event can have up to %d properties, got %d", maxEventProperties, len(properties))
+	}
+
+	for key := range properties {
+		if key == "" || strings.ContainsAny(key, ":,") {
+			return fmt.Errorf("invalid property name %q", key)
+		}
+	}
+
+	return nil
+}
+
+const maxUserIDLength = 255
+
+func validateUserID(userID string) error {
+	if len(userID) > maxUserIDLength {
+		return fmt.Errorf("the \"user_id\" must have at most %d characters", maxUserIDLength)
+	}
+
+	return nil
+}
+
+func parsePropertyQuery(queryParams url.Values) (query model.PropertyQuery, err error) {
+	for _, groupBy := range queryParams["group_by"] {
+		for _, key := range strings.Split(groupBy, ",") {
+			if key == "" {
+				return model.PropertyQuery{}, errors.New("Error parsing \"group_by\" query parameter: empty property name")
+			}
+			query.GroupBy = append(query.GroupBy, key)
+		}
+	}
+
+	for _, where := range queryParams["where"] {
+		key, value, found := strings.Cut(where, ":")
+		if !found || key == "" {
+			return model.PropertyQuery{}, fmt.Errorf("Error parsing \"where\" query parameter: %q must be in the format \"property:value\"", where)
+		}
+
+		if query.Where == nil {
+			query.Where = map[string]string{}
+		}
+		query.Where[key] = value
+	}
+
+	return query, nil
+}
+
+func parseTimeParam(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("missing value")
+	}
+
+	parsedTime, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return parsedTime.In(location), nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		parsedTime, err = time.ParseInLocation(layout, value, location)
+		if err == nil {
+			return parsedTime, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q must be in RFC3339, \"YYYY-MM-DD HH:mm:ss\" or \"YYYY-MM-DD\" format", value)
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"eventTracker/internal/auth"
+	"eventTracker/internal/db"
+	"eventTracker/internal/event"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// testAPIKey is the admin key of the environments of newTestEnv.
+const testAPIKey = "test-admin-key"
+
+// newTestEnv returns an environment on a migrated storage of the backend, with the admin key testAPIKey.
+func newTestEnv(t *testing.T, backend string) (Env, db.Storage) {
+	t.Helper()
+
+	storage, err := db.OpenStorage(backend, t.TempDir()+"/events.db", false)
+	if err != nil {
+		t.Fatalf("opening the %s storage: %v", backend, err)
+	}
+	if storage.Database != nil {
+		t.Cleanup(func() { _ = storage.Database.Close() })
+	}
+	if _, err = storage.MigrateUp(); err != nil {
+		t.Fatalf("migrating the %s storage up: %v", backend, err)
+	}
+
+	env := Env{
+		EventService:             event.EventService{},
+		EventDBHandler:           storage.EventDBHandler,
+		EventFreqDBHandler:       storage.EventFreqDBHandler,
+		EventIngestHandler:       storage.EventIngestHandler,
+		EventPropertyDBHandler:   storage.EventPropertyDBHandler,
+		EventOccurrenceDBHandler: storage.EventOccurrenceDBHandler,
+		EventRetentionDBHandler:  storage.EventRetentionDBHandler,
+		EventRollupDBHandler:     storage.EventRollupDBHandler,
+		EventUniqueDBHandler:     storage.EventUniqueDBHandler,
+		EventValueDBHandler:      storage.EventValueDBHandler,
+		KeyService:               auth.KeyService{},
+		APIKeyDBHandler:          storage.APIKeyDBHandler,
+		AlertRuleDBHandler:       storage.AlertRuleDBHandler,
+	}
+
+	err = env.KeyService.EnsureAPIKey(env.APIKeyDBHandler, "test", testAPIKey, []string{auth.ScopeAdmin}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return env, storage
+}
+
+// newTestRouter returns a router that authenticates the requests and routes the pattern to the handler.
+func newTestRouter(env Env, method, pattern string, handler func(Env, http.ResponseWriter, *http.Request)) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(env.AuthMiddleware)
+	router.HandleFunc(pattern, env.inProject(handler)).Methods(method)
+
+	return router
+}
+
+// serveTestRequest serves a request with the API key on the router.
+func serveTestRequest(router http.Handler, key, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("x-api-key", key)
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestCreateEventConcurrently(t *testing.T) {
+	const (
+		requests = 2000
+		workers  = 50
+	)
+
+	for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			env, storage := newTestEnv(t, backend)
+			server := httptest.NewServer(newTestRouter(env, http.MethodPost, "/api/v1/events/{name}", Env.CreateEvent))
+			defer server.Close()
+
+			// The even requests record 2 logins at 10h on 2021-01-01 (a Friday), the odd ones 1 at 23h on 2021-01-02.
+			bodies := []string{
+				`{"count": 2, "date": "2021-01-01T10:15:00Z"}`,
+				`{"count": 1, "date": "2021-01-02 23:59:00"}`,
+			}
+
+			jobs := make(chan int)
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for job := range jobs {
+						request, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/events/login", strings.NewReader(bodies[job%2]))
+						if err != nil {
+							t.Error(err)
+							continue
+						}
+						request.Header.Set("x-api-key", testAPIKey)
+
+						response, err := server.Client().Do(request)
+						if err != nil {
+							t.Error(err)
+							continue
+						}
+						_ = response.Body.Close()
+						if response.StatusCode != http.StatusCreated {
+							t.Errorf("request %d got status %d, want %d", job, response.StatusCode, http.StatusCreated)
+						}
+					}
+				}()
+			}
+			for i := 0; i < requests; i++ {
+				jobs <- i
+			}
+			close(jobs)
+			wg.Wait()
+
+			half := uint64(requests / 2)
+
+			events, err := storage.EventDBHandler.GetEventsByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := map[string]uint64{}
+			for _, event := range events {
+				counts[event.Date] += event.Count
+			}
+			if len(events) != 2 || counts["2021-01-01"] != 2*half || counts["2021-01-02"] != half {
+				t.Fatalf("login events = %v, want %d occurrences on 2021-01-01 and %d on 2021-01-02", events, 2*half, half)
+			}
+
+			eventFreq, err := storage.EventFreqDBHandler.GetEventByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eventFreq.TotalCount != 3*half || eventFreq.HourCount[10] != 2*half || eventFreq.HourCount[23] != half {
+				t.Fatalf("login frequency = %+v, want %d occurrences, %d at 10h and %d at 23h", eventFreq, 3*half, 2*half, half)
+			}
+			if eventFreq.WeekdayHourCount[time.Friday][10] != 2*half || eventFreq.WeekdayHourCount[time.Saturday][23] != half {
+				t.Fatalf("login weekday hours = %v, want %d on Friday at 10h and %d on Saturday at 23h", eventFreq.WeekdayHourCount, 2*half, half)
+			}
+		})
+	}
+}
+
+func TestPlotEventWithoutOccurrences(t *testing.T) {
+	env, storage := newTestEnv(t, db.StorageMemory)
+
+	date := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	if err := env.EventService.CreateEvent(storage.EventIngestHandler, "login", 3, date, nil, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	// Pruning every day of the event leaves its frequency with a total of 0.
+	if _, err := storage.EventRetentionDBHandler.PruneEvents("login", "2021-01-02", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plot := range []struct {
+		path    string
+		handler func(Env, http.ResponseWriter, *http.Request)
+	}{
+		{"/api/v1/event_frequencies/{name}/hist", Env.ReturnEventFrequencyHistogram},
+		{"/api/v1/event_frequencies/{name}/heatmap", Env.ReturnEventFrequencyHeatmap},
+	} {
+		router := newTestRouter(env, http.MethodGet, plot.path, plot.handler)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, strings.Replace(plot.path, "{name}", "login", 1), nil)
+		r.Header.Set("x-api-key", testAPIKey)
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Fatalf("%s got status %d, want a plot: %s", plot.path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCreateEventsBatchLimits(t *testing.T) {
+	env, storage := newTestEnv(t, db.StorageMemory)
+	router := newTestRouter(env, http.MethodPost, "/api/v1/events:batch", Env.CreateEventsBatch)
+
+	item := `{"event": "login", "date": "2021-01-01T10:00:00Z"}`
+	items := func(n int, separator string) string {
+		return strings.TrimSuffix(strings.Repeat(item+separator, n), separator)
+	}
+
+	for _, test := range []struct {
+		name, contentType, body string
+		want                    int
+	}{
+		{"array", "application/json", "[" + items(maxBatchItems, ",") + "]", http.StatusCreated},
+		{"too many items", "application/json", "[" + items(maxBatchItems+1, ",") + "]", http.StatusRequestEntityTooLarge},
+		{"too many lines", "application/x-ndjson", items(maxBatchItems+1, "\n"), http.StatusRequestEntityTooLarge},
+		{"too many bytes", "application/json", `[{"event": "login", "properties": {"padding": "` + strings.Repeat("x", maxBatchBytes) + `"}}]`, http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/events:batch", strings.NewReader(test.body))
+			r.Header.Set("x-api-key", testAPIKey)
+			r.Header.Set("Content-Type", test.contentType)
+			router.ServeHTTP(w, r)
+
+			if w.Code != test.want {
+				t.Fatalf("got status %d, want %d: %.200s", w.Code, test.want, w.Body.String())
+			}
+		})
+	}
+
+	// Only the batch within the limits was recorded.
+	eventFreq, err := storage.EventFreqDBHandler.GetEventByName("login")
+	if err != nil || eventFreq.TotalCount != maxBatchItems {
+		t.Fatalf("login frequency = %+v, %v, want %d occurrences", eventFreq, err, maxBatchItems)
+	}
+}
+
+func TestCreateEventIdempotentRetries(t *testing.T) {
+	for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			env, storage := newTestEnv(t, backend)
+			router := newTestRouter(env, http.MethodPost, "/api/v1/events/{name}", Env.CreateEvent)
+
+			create := func(idempotencyKey, body string) *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodPost, "/api/v1/events/login", strings.NewReader(body))
+				r.Header.Set("x-api-key", testAPIKey)
+				if idempotencyKey != "" {
+					r.Header.Set("Idempotency-Key", idempotencyKey)
+				}
+				router.ServeHTTP(w, r)
+
+				return w
+			}
+
+			// The retries of a request carry its key either in the header or in the body.
+			for i, retry := range []struct{ idempotencyKey, body string }{
+				{"req-1", `{"count": 3, "date": "2021-01-01 10:00:00"}`},
+				{"req-1", `{"count": 3, "date": "2021-01-01 10:00:00"}`},
+				{"", `{"id": "req-1", "count": 3, "date": "2021-01-01 10:00:00"}`},
+			} {
+				w := create(retry.idempotencyKey, retry.body)
+				if w.Code != http.StatusCreated {
+					t.Fatalf("attempt %d got status %d: %s", i, w.Code, w.Body.String())
+				}
+				if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != (i > 0) {
+					t.Fatalf("attempt %d was replayed: %v, want %v", i, replayed, i > 0)
+				}
+			}
+
+			if w := create("req-1", `{"count": 4, "date": "2021-01-01 10:00:00"}`); w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("reusing the key for another request got status %d, want %d", w.Code, http.StatusUnprocessableEntity)
+			}
+			if w := create("req-2", `{"id": "req-3", "count": 1}`); w.Code != http.StatusBadRequest {
+				t.Fatalf("different keys in the header and the body got status %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if w := create("req-2", `{"count": 1, "date": "2021-01-01 11:00:00"}`); w.Code != http.StatusCreated {
+				t.Fatalf("a new key got status %d: %s", w.Code, w.Body.String())
+			}
+
+			eventFreq, err := storage.EventFreqDBHandler.GetEventByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eventFreq.TotalCount != 4 {
+				t.Fatalf("login has %d occurrences, want 4", eventFreq.TotalCount)
+			}
+		})
+	}
+}
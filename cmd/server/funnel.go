@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxFunnelSteps = 10
+
+func (env Env) ReturnEventFunnel(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	steps, err := parseFunnelSteps(queryParams.Get("steps"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	startDate, endDate := queryParams.Get("start_date"), queryParams.Get("end_date")
+	for _, param := range []string{"start_date", "end_date"} {
+		_, err = time.Parse("2006-01-02", queryParams.Get(param))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error parsing \"%s\" query parameter: %s", param, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	location, err := parseLocation(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	funnel, err := env.EventService.EventFunnel(env.EventOccurrenceDBHandler, steps, startDate, endDate, location)
+	if errors.Is(err, model.ErrInvalidRange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(funnel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func parseFunnelSteps(value string) ([]string, error) {
+	if value == "" {
+		return nil, errors.New("The \"steps\" query parameter is required")
+	}
+
+	steps := strings.Split(value, ",")
+	if len(steps) < 2 || len(steps) > maxFunnelSteps {
+		return nil, fmt.Errorf("A funnel must have from 2 to %d steps, got %d", maxFunnelSteps, len(steps))
+	}
+
+	seen := map[string]bool{}
+	for _, step := range steps {
+		if step == "" {
+			return nil, errors.New("The \"steps\" query parameter has an empty event name")
+		}
+		if seen[step] {
+			return nil, fmt.Errorf("The event %s is more than once in the \"steps\"", step)
+		}
+		seen[step] = true
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/auth"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"fmt"
+	"github.com/gorilla/mux"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (env Env) ReturnAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := env.KeyService.APIKeys(env.APIKeyDBHandler)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(keys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateAPIKey answers with the only copy of the key.
+func (env Env) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var body model.APIKeyBody
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Json decoder error: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if body.Name == "" {
+		http.Error(w, "The \"name\" of the key is required", http.StatusBadRequest)
+		return
+	}
+
+	scopes, err := auth.ValidateScopes(body.Scopes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var expiresAt time.Time
+	if body.ExpiresAt != "" {
+		expiresAt, err = time.Parse(time.RFC3339, body.ExpiresAt)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error trying to decode \"expires_at\": %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		if !expiresAt.After(now) {
+			http.Error(w, "The \"expires_at\" of the key must be in the future", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limits, err := validateLimits(body.APIKeyLimitsBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limits != (model.APIKeyLimits{}) && !canSetLimits(w, r, 0) {
+		return
+	}
+
+	createdKey, err := env.KeyService.CreateAPIKey(env.APIKeyDBHandler, body.Name, scopes, limits, expiresAt, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(createdKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	rotatedKey, err := env.KeyService.RotateAPIKey(env.APIKeyDBHandler, ID)
+	if errors.Is(err, model.ErrAPIKeyNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), ID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(rotatedKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	err := env.KeyService.RevokeAPIKey(env.APIKeyDBHandler, ID, time.Now())
+	if errors.Is(err, model.ErrAPIKeyNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), ID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (env Env) SetAPIKeyLimits(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseKeyID(w, r)
+	if !ok || !canSetLimits(w, r, ID) {
+		return
+	}
+
+	var body model.APIKeyLimitsBody
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Json decoder error: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	limits, err := validateLimits(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedKey, err := env.KeyService.SetAPIKeyLimits(env.APIKeyDBHandler, ID, limits)
+	if errors.Is(err, model.ErrAPIKeyNotFound) {
+		http.Error(w, fmt.Sprintf(err.Error(), ID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(updatedKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func validateLimits(body model.APIKeyLimitsBody) (limits model.APIKeyLimits, err error) {
+	if body.RateLimit != nil {
+		if *body.RateLimit <= 0 || math.IsNaN(*body.RateLimit) || math.IsInf(*body.RateLimit, 0) {
+			return model.APIKeyLimits{}, errors.New("The \"rate_limit\" of the key must be a positive number of requests per second, or left out for the default")
+		}
+		limits.RateLimit = *body.RateLimit
+	}
+	if body.Burst != nil {
+		if *body.Burst == 0 {
+			return model.APIKeyLimits{}, errors.New("The \"burst\" of the key must be positive, or left out for the default")
+		}
+		limits.Burst = *body.Burst
+	}
+	if body.DailyQuota != nil {
+		if *body.DailyQuota == 0 {
+			return model.APIKeyLimits{}, errors.New("The \"daily_quota\" of the key must be positive, or left out for the default")
+		}
+		limits.DailyQuota = *body.DailyQuota
+	}
+
+	return limits, nil
+}
+
+// Only the admin keys of the default project set limits, and never their own. ID is 0 for a new key.
+func canSetLimits(w http.ResponseWriter, r *http.Request, ID uint64) bool {
+	apiKey, ok := apiKeyFromContext(r)
+	if !ok || apiKey.Project != db.DefaultProject {
+		http.Error(w, model.ErrLimitsForbidden.Error(), http.StatusForbidden)
+		return false
+	}
+	project, _ := r.Context().Value(projectContextKey).(string)
+	if apiKey.ID == ID && project == apiKey.Project {
+		http.Error(w, model.ErrOwnLimits.Error(), http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func parseKeyID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	params := mux.Vars(r)
+
+	ID, err := strconv.ParseUint(params["id"], 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing the key id: %s", err.Error()), http.StatusBadRequest)
+		return 0, false
+	}
+
+	return ID, true
+}
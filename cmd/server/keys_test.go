@@ -0,0 +1,135 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyCreateAndRevoke(t *testing.T) {
+	for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			env, storage := newTestEnv(t, backend)
+			router := newTestRouter(env, http.MethodPost, "/admin/v1/keys", Env.CreateAPIKey)
+			router.HandleFunc("/admin/v1/keys", env.inProject(Env.ReturnAPIKeys)).Methods(http.MethodGet)
+			router.HandleFunc("/admin/v1/keys/{id}", env.inProject(Env.RevokeAPIKey)).Methods(http.MethodDelete)
+			router.HandleFunc("/api/v1/events", env.inProject(Env.ReturnEvents)).Methods(http.MethodGet)
+			router.HandleFunc("/api/v1/events/{name}", env.inProject(Env.CreateEvent)).Methods(http.MethodPost)
+
+			w := serveTestRequest(router, testAPIKey, http.MethodPost, "/admin/v1/keys", `{"name": "reader", "scopes": ["read"]}`)
+			var createdKey model.CreatedAPIKey
+			if err := json.NewDecoder(w.Body).Decode(&createdKey); err != nil || w.Code != http.StatusCreated {
+				t.Fatalf("creating the key got status %d, %v", w.Code, err)
+			}
+			if createdKey.Key == "" || !strings.HasPrefix(createdKey.Key, createdKey.Prefix) {
+				t.Fatalf("created key = %+v, want the key with its prefix", createdKey)
+			}
+
+			// Only the hash of the key is stored, and it is what the requests are authorized with.
+			storedKey, err := storage.APIKeyDBHandler.GetAPIKeyByHash(hashTestKey(createdKey.Key))
+			if err != nil || storedKey.ID != createdKey.ID {
+				t.Fatalf("the key looked up by its hash is %+v, %v, want the key %d", storedKey, err, createdKey.ID)
+			}
+			w = serveTestRequest(router, testAPIKey, http.MethodGet, "/admin/v1/keys", "")
+			if strings.Contains(w.Body.String(), createdKey.Key) {
+				t.Fatalf("the list of the keys shows the key: %s", w.Body.String())
+			}
+
+			if w = serveTestRequest(router, createdKey.Key, http.MethodGet, "/api/v1/events", ""); w.Code != http.StatusOK {
+				t.Fatalf("reading with the key got status %d, want %d", w.Code, http.StatusOK)
+			}
+			if w = serveTestRequest(router, createdKey.Key, http.MethodPost, "/api/v1/events/login", `{}`); w.Code != http.StatusForbidden {
+				t.Fatalf("ingesting with a read key got status %d, want %d", w.Code, http.StatusForbidden)
+			}
+			if w = serveTestRequest(router, createdKey.Key, http.MethodGet, "/admin/v1/keys", ""); w.Code != http.StatusForbidden {
+				t.Fatalf("listing the keys with a read key got status %d, want %d", w.Code, http.StatusForbidden)
+			}
+
+			revokePath := "/admin/v1/keys/" + strconv.FormatUint(createdKey.ID, 10)
+			if w = serveTestRequest(router, testAPIKey, http.MethodDelete, revokePath, ""); w.Code != http.StatusNoContent {
+				t.Fatalf("revoking the key got status %d: %s", w.Code, w.Body.String())
+			}
+			if w = serveTestRequest(router, createdKey.Key, http.MethodGet, "/api/v1/events", ""); w.Code != http.StatusForbidden {
+				t.Fatalf("reading with the revoked key got status %d, want %d", w.Code, http.StatusForbidden)
+			}
+			if w = serveTestRequest(router, testAPIKey, http.MethodDelete, "/admin/v1/keys/999", ""); w.Code != http.StatusNotFound {
+				t.Fatalf("revoking a missing key got status %d, want %d", w.Code, http.StatusNotFound)
+			}
+
+			w = serveTestRequest(router, testAPIKey, http.MethodGet, "/admin/v1/keys", "")
+			var keys []model.APIKey
+			if err = json.NewDecoder(w.Body).Decode(&keys); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range keys {
+				if key.ID == createdKey.ID && key.RevokedAt == "" {
+					t.Fatalf("the revoked key is listed without its revocation: %+v", key)
+				}
+			}
+		})
+	}
+}
+
+func TestSetAPIKeyLimits(t *testing.T) {
+	env, storage := newTestEnv(t, db.StorageMemory)
+	router := newTestRouter(env, http.MethodPut, "/admin/v1/keys/{id}/limits", Env.SetAPIKeyLimits)
+	router.HandleFunc("/admin/v1/keys", env.inProject(Env.CreateAPIKey)).Methods(http.MethodPost)
+
+	adminKey, err := storage.APIKeyDBHandler.GetAPIKeyByHash(hashTestKey(testAPIKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectKey, err := env.KeyService.CreateAPIKey(storage.APIKeyDBHandler.ForProject("acme"), "acme", []string{"admin"}, model.APIKeyLimits{}, time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	limitsPath := func(ID uint64) string {
+		return "/admin/v1/keys/" + strconv.FormatUint(ID, 10) + "/limits"
+	}
+
+	for _, test := range []struct {
+		name, key, project, method, target, body string
+		want                                     int
+	}{
+		{"own limits of a project admin", projectKey.Key, "", http.MethodPut, limitsPath(projectKey.ID), `{"rate_limit": 1000}`, http.StatusForbidden},
+		{"new key with limits of a project admin", projectKey.Key, "", http.MethodPost, "/admin/v1/keys", `{"name": "k", "scopes": ["ingest"], "daily_quota": 10}`, http.StatusForbidden},
+		{"new key without limits of a project admin", projectKey.Key, "", http.MethodPost, "/admin/v1/keys", `{"name": "k", "scopes": ["ingest"]}`, http.StatusCreated},
+		{"own limits of the default admin", testAPIKey, "", http.MethodPut, limitsPath(adminKey.ID), `{"rate_limit": 1000}`, http.StatusForbidden},
+		{"zero rate limit", testAPIKey, "acme", http.MethodPut, limitsPath(projectKey.ID), `{"rate_limit": 0}`, http.StatusBadRequest},
+		{"zero burst", testAPIKey, "acme", http.MethodPut, limitsPath(projectKey.ID), `{"burst": 0}`, http.StatusBadRequest},
+		{"project key of the default admin", testAPIKey, "acme", http.MethodPut, limitsPath(projectKey.ID), `{"rate_limit": 5, "daily_quota": 100}`, http.StatusOK},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			r.Header.Set("x-api-key", test.key)
+			r.Header.Set("x-project", test.project)
+			router.ServeHTTP(w, r)
+
+			if w.Code != test.want {
+				t.Fatalf("got status %d, want %d: %s", w.Code, test.want, w.Body.String())
+			}
+		})
+	}
+
+	updatedKey, err := storage.APIKeyDBHandler.GetAPIKeyByHash(hashTestKey(projectKey.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedKey.APIKeyLimits != (model.APIKeyLimits{RateLimit: 5, DailyQuota: 100}) {
+		t.Fatalf("limits of the project key = %+v, want the ones set by the default admin", updatedKey.APIKeyLimits)
+	}
+}
+
+func hashTestKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultLiveInterval = time.Second
+	minLiveInterval     = 250 * time.Millisecond
+	maxLiveInterval     = time.Minute
+	maxLiveEvents       = 100
+	liveWriteWait       = 10 * time.Second
+)
+
+// The clients authenticate with their API key, not with cookies, so any origin can connect.
+var liveUpgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+type liveMessage struct {
+	subscription model.LiveSubscription
+	err          error
+}
+
+func (env Env) StreamLiveCounters(w http.ResponseWriter, r *http.Request) {
+	if env.Broker == nil {
+		http.Error(w, "The live counters are disabled", http.StatusNotFound)
+		return
+	}
+
+	interval := defaultLiveInterval
+	if value := r.URL.Query().Get("interval"); value != "" {
+		var err error
+		interval, err = time.ParseDuration(value)
+		if err != nil || interval < minLiveInterval || interval > maxLiveInterval {
+			http.Error(w, fmt.Sprintf("Invalid \"interval\" query parameter %s, must be a duration from %s to %s", value, minLiveInterval, maxLiveInterval), http.StatusBadRequest)
+			return
+		}
+	}
+
+	project, _ := r.Context().Value(projectContextKey).(string)
+	subscription, err := env.Broker.Subscribe(project, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer env.Broker.Unsubscribe(subscription)
+
+	// Upgrade answers the failed handshakes itself.
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(64 * 1024)
+
+	done := make(chan struct{})
+	defer close(done)
+	messages := make(chan liveMessage)
+	go readLiveMessages(conn, messages, done)
+
+	counters := event.NewLiveCounters(time.Now())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			if message.err == nil {
+				message.err = env.updateLiveCounters(counters, subscription, message.subscription)
+			}
+			if message.err != nil {
+				err = writeLiveMessage(conn, map[string]string{"type": "error", "error": message.err.Error()})
+			} else {
+				err = writeLiveMessage(conn, liveCountersMessage(counters, subscription, time.Now()))
+			}
+		case occurrence, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+
+			counters.Record(occurrence, time.Now())
+		case <-ticker.C:
+			err = writeLiveMessage(conn, liveCountersMessage(counters, subscription, time.Now()))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readLiveMessages(conn *websocket.Conn, messages chan<- liveMessage, done <-chan struct{}) {
+	defer close(messages)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var message liveMessage
+		err = json.Unmarshal(data, &message.subscription)
+		if err != nil {
+			message.err = fmt.Errorf("json decoder error: %s", err.Error())
+		}
+
+		select {
+		case messages <- message:
+		case <-done:
+			return
+		}
+	}
+}
+
+// The occurrences buffered by the time the hour count is read are already in it, so they are recorded
+// before the event is added.
+func (env Env) updateLiveCounters(counters *event.LiveCounters, subscription *event.Subscription, changes model.LiveSubscription) error {
+	for _, name := range changes.Unsubscribe {
+		counters.Remove(name)
+	}
+
+	for _, name := range changes.Subscribe {
+		if name == "" {
+			return fmt.Errorf("missing event name")
+		}
+		if counters.Has(name) {
+			continue
+		}
+		if counters.Len() >= maxLiveEvents {
+			return fmt.Errorf("a client can subscribe to up to %d events", maxLiveEvents)
+		}
+
+		err := env.Broker.Snapshot(func() error {
+			hourCount, err := env.EventService.LiveHourCount(env.EventOccurrenceDBHandler, name, counters.Hour())
+			if err != nil {
+				return err
+			}
+
+			for len(subscription.Events()) > 0 {
+				counters.Record(<-subscription.Events(), time.Now())
+			}
+			counters.Add(name, hourCount)
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func liveCountersMessage(counters *event.LiveCounters, subscription *event.Subscription, now time.Time) model.LiveCountersMessage {
+	tick := counters.Tick(now)
+
+	return model.LiveCountersMessage{
+		Type:     "counters",
+		Time:     now.UTC().Format(time.RFC3339),
+		Hour:     counters.Hour().Format(time.RFC3339),
+		Counters: tick,
+		Dropped:  subscription.TakeDropped(),
+	}
+}
+
+func writeLiveMessage(conn *websocket.Conn, message interface{}) error {
+	err := conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+	if err != nil {
+		return err
+	}
+
+	return conn.WriteJSON(message)
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestStreamLiveCounters(t *testing.T) {
+	server := newStreamTestServer(t)
+
+	// Near the end of an hour the occurrences could be dated in the next one, and not count in hour_count.
+	if now := time.Now(); now.Add(5*time.Second).Hour() != now.Hour() {
+		time.Sleep(5 * time.Second)
+	}
+
+	postTestEvents(t, server, `[{"event": "login", "count": 5}]`)
+
+	header := http.Header{}
+	header.Set("x-api-key", testAPIKey)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/events/live?interval=250ms", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The connection is subscribed to the broker before subscribing to login, so these occurrences are
+	// both stored and buffered for it, and must only be counted once.
+	postTestEvents(t, server, `[{"event": "login", "count": 3}, {"event": "logout"}]`)
+
+	if err = conn.WriteJSON(model.LiveSubscription{Subscribe: []string{"login"}}); err != nil {
+		t.Fatal(err)
+	}
+	counter := readLiveCounter(t, conn, "login", func(counter model.LiveCounter) bool { return true })
+	if counter.HourCount != 8 {
+		t.Fatalf("login counter after subscribing = %+v, want a hour_count of 8", counter)
+	}
+
+	postTestEvents(t, server, `[{"event": "login", "count": 2}, {"event": "logout", "count": 4}]`)
+
+	var delta uint64
+	counter = readLiveCounter(t, conn, "login", func(counter model.LiveCounter) bool {
+		delta += counter.Delta
+		return delta >= 2
+	})
+	if delta != 2 || counter.HourCount != 10 {
+		t.Fatalf("login counter = %+v with a delta of %d since subscribing, want 2 and a hour_count of 10", counter, delta)
+	}
+}
+
+func TestUpdateLiveCountersSkipsBufferedOccurrences(t *testing.T) {
+	env, _ := newTestEnv(t, db.StorageMemory)
+	env.Broker = event.NewBroker(64)
+	env.EventIngestHandler = event.PublishingIngestHandler{EventIngestHandler: env.EventIngestHandler, Broker: env.Broker, Project: db.DefaultProject}
+
+	subscription, err := env.Broker.Subscribe(db.DefaultProject, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Broker.Unsubscribe(subscription)
+
+	now := time.Now()
+	counters := event.NewLiveCounters(now)
+	err = env.EventIngestHandler.IngestEvents([]model.EventOccurrence{{Name: "login", Count: 3, Date: counters.Hour()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The occurrence is both stored and still buffered in the subscription.
+	if err = env.updateLiveCounters(counters, subscription, model.LiveSubscription{Subscribe: []string{"login"}}); err != nil {
+		t.Fatal(err)
+	}
+	for len(subscription.Events()) > 0 {
+		counters.Record(<-subscription.Events(), now)
+	}
+
+	tick := counters.Tick(now)
+	if len(tick) != 1 || tick[0].HourCount != 3 || tick[0].Delta != 0 {
+		t.Fatalf("counters = %+v, want a hour_count of 3 and no delta", tick)
+	}
+}
+
+// readLiveCounter reads the counters messages until the counter of the event is done.
+func readLiveCounter(t *testing.T, conn *websocket.Conn, name string, done func(model.LiveCounter) bool) model.LiveCounter {
+	t.Helper()
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var message model.LiveCountersMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatal(err)
+		}
+		if message.Type != "counters" {
+			t.Fatalf("got a %s message, want counters", message.Type)
+		}
+
+		for _, counter := range message.Counters {
+			if counter.Name == name && done(counter) {
+				return counter
+			}
+		}
+	}
+}
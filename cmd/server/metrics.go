@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"eventTracker/internal/metrics"
+	"eventTracker/internal/model"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MetricsMiddleware labels the requests with the template of their route, so that every event shares the same series.
+func (env Env) MetricsMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		h.ServeHTTP(recorder, r)
+
+		metrics.RequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(recorder.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response doesn't support hijacking")
+	}
+
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// The totals of ReturnMetrics go down when the retention prunes occurrences, which Prometheus takes as a counter reset.
+func (env Env) ReturnMetrics(w http.ResponseWriter, r *http.Request) {
+	hourly := false
+	if value := r.URL.Query().Get("hourly"); value != "" {
+		var err error
+		hourly, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid \"hourly\" query parameter "+value+", must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	project, _ := r.Context().Value(projectContextKey).(string)
+
+	events, err := env.EventService.AllEventsFrequencies(env.EventFreqDBHandler)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	err = registry.Register(eventCollector{project: project, events: events, hourly: hourly})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	promhttp.HandlerFor(prometheus.Gatherers{registry, metrics.Registry}, promhttp.HandlerOpts{ErrorHandling: promhttp.HTTPErrorOnError}).ServeHTTP(w, r)
+}
+
+var (
+	eventOccurrencesDesc = prometheus.NewDesc("eventtracker_event_occurrences_total",
+		"Occurrences recorded of each event.", []string{"project", "event"}, nil)
+	eventHourOccurrencesDesc = prometheus.NewDesc("eventtracker_event_hour_occurrences_total",
+		"Occurrences recorded of each event by hour of the day (UTC).", []string{"project", "event", "hour"}, nil)
+)
+
+type eventCollector struct {
+	project string
+	events  []model.EventFreq
+	hourly  bool
+}
+
+func (c eventCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- eventOccurrencesDesc
+	if c.hourly {
+		descs <- eventHourOccurrencesDesc
+	}
+}
+
+func (c eventCollector) Collect(samples chan<- prometheus.Metric) {
+	for _, event := range c.events {
+		samples <- prometheus.MustNewConstMetric(eventOccurrencesDesc, prometheus.CounterValue, float64(event.TotalCount), c.project, event.Name)
+
+		if !c.hourly {
+			continue
+		}
+		for hour, count := range event.HourCount {
+			samples <- prometheus.MustNewConstMetric(eventHourOccurrencesDesc, prometheus.CounterValue, float64(count), c.project, event.Name, strconv.Itoa(hour))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"eventTracker/internal/auth"
+	"eventTracker/internal/db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestReturnMetrics(t *testing.T) {
+	env, storage := newTestEnv(t, db.StorageSQLite)
+
+	err := env.KeyService.EnsureAPIKey(env.APIKeyDBHandler, "reader", "test-read-key", []string{auth.ScopeRead}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	if err = env.EventService.CreateEvent(storage.EventIngestHandler, "login", 3, date, nil, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The requests are timed before being authenticated, as in HandleRequests.
+	router := mux.NewRouter()
+	router.Use(env.MetricsMiddleware)
+	router.Use(env.AuthMiddleware)
+	router.HandleFunc("/metrics", env.inProject(Env.ReturnMetrics)).Methods(http.MethodGet)
+
+	scrape := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/metrics?hourly=true", nil)
+		r.Header.Set("x-api-key", key)
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := scrape("test-read-key"); w.Code != http.StatusForbidden {
+		t.Fatalf("a read key got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	w := scrape(testAPIKey)
+	if w.Code != http.StatusOK {
+		t.Fatalf("an admin key got status %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	for _, want := range []string{
+		`eventtracker_event_occurrences_total{event="login",project="default"} 3`,
+		`eventtracker_event_hour_occurrences_total{event="login",hour="10",project="default"} 3`,
+		`eventtracker_db_query_duration_seconds_count{backend="sqlite",handler="EventIngest",operation="IngestEvents"}`,
+		`eventtracker_http_request_duration_seconds_count{code="403",method="GET",route="/metrics"}`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Fatalf("the metrics don't have %s:\n%s", want, w.Body.String())
+		}
+	}
+}
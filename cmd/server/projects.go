@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"eventTracker/internal/model"
+	"net/http"
+)
+
+type contextKey string
+
+const (
+	apiKeyContextKey  contextKey = "apiKey"
+	projectContextKey contextKey = "project"
+)
+
+func withAPIKey(r *http.Request, apiKey model.APIKey, project string) *http.Request {
+	ctx := context.WithValue(r.Context(), apiKeyContextKey, apiKey)
+	return r.WithContext(context.WithValue(ctx, projectContextKey, project))
+}
+
+func apiKeyFromContext(r *http.Request) (model.APIKey, bool) {
+	apiKey, ok := r.Context().Value(apiKeyContextKey).(model.APIKey)
+	return apiKey, ok
+}
+
+func (env Env) inProject(handler func(Env, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		project, ok := r.Context().Value(projectContextKey).(string)
+		if !ok {
+			http.Error(w, "The project of the request is unknown", http.StatusInternalServerError)
+			return
+		}
+
+		handler(env.forProject(project), w, r)
+	}
+}
+
+func (env Env) forProject(project string) Env {
+	env.EventDBHandler = env.EventDBHandler.ForProject(project)
+	env.EventFreqDBHandler = env.EventFreqDBHandler.ForProject(project)
+	env.EventIngestHandler = env.EventIngestHandler.ForProject(project)
+	env.EventPropertyDBHandler = env.EventPropertyDBHandler.ForProject(project)
+	env.EventOccurrenceDBHandler = env.EventOccurrenceDBHandler.ForProject(project)
+	env.EventRetentionDBHandler = env.EventRetentionDBHandler.ForProject(project)
+	env.EventRollupDBHandler = env.EventRollupDBHandler.ForProject(project)
+	env.EventUniqueDBHandler = env.EventUniqueDBHandler.ForProject(project)
+	env.EventValueDBHandler = env.EventValueDBHandler.ForProject(project)
+	env.APIKeyDBHandler = env.APIKeyDBHandler.ForProject(project)
+	env.AlertRuleDBHandler = env.AlertRuleDBHandler.ForProject(project)
+
+	return env
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"eventTracker/internal/auth"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectIsolation(t *testing.T) {
+	for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			env, storage := newTestEnv(t, backend)
+			router := NewRouter(env)
+
+			createKey := func(project string, scopes ...string) model.CreatedAPIKey {
+				createdKey, err := env.KeyService.CreateAPIKey(storage.APIKeyDBHandler.ForProject(project), project, scopes, model.APIKeyLimits{}, time.Time{}, time.Now())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return createdKey
+			}
+			keyA, keyB := createKey("acme", auth.ScopeAdmin), createKey("globex", auth.ScopeAdmin)
+			readerKey := createKey(db.DefaultProject, auth.ScopeRead, auth.ScopeIngest)
+
+			err := storage.EventIngestHandler.ForProject("globex").IngestEvents([]model.EventOccurrence{{Name: "login", Count: 3, Date: time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = storage.EventRetentionDBHandler.ForProject("globex").SetRetention("", 30); err != nil {
+				t.Fatal(err)
+			}
+
+			serve := func(key, project, method, target, body string) *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(method, target, strings.NewReader(body))
+				r.Header.Set("x-api-key", key)
+				r.Header.Set("x-project", project)
+				router.ServeHTTP(w, r)
+
+				return w
+			}
+
+			// The keys of other projects, and the keys of the default project without the admin scope,
+			// can't pick the project of their requests.
+			for _, request := range []struct{ key, method, target, body string }{
+				{keyA.Key, http.MethodGet, "/api/v1/events", ""},
+				{keyA.Key, http.MethodPost, "/api/v1/events/login", `{"count": 1}`},
+				{keyA.Key, http.MethodGet, "/admin/v1/keys", ""},
+				{keyA.Key, http.MethodPost, "/admin/v1/keys", `{"name": "k", "scopes": ["admin"]}`},
+				{keyA.Key, http.MethodDelete, "/admin/v1/keys/" + strconv.FormatUint(keyB.ID, 10), ""},
+				{keyA.Key, http.MethodPut, "/admin/v1/retention", `{"days": 1}`},
+				{keyA.Key, http.MethodDelete, "/admin/v1/events/login", ""},
+				{readerKey.Key, http.MethodGet, "/api/v1/events", ""},
+				{readerKey.Key, http.MethodPost, "/api/v1/events/login", `{"count": 1}`},
+			} {
+				if w := serve(request.key, "globex", request.method, request.target, request.body); w.Code != http.StatusForbidden {
+					t.Fatalf("%s %s on another project got status %d, want %d", request.method, request.target, w.Code, http.StatusForbidden)
+				}
+			}
+
+			// In its own project, the admin key of acme doesn't see nor change the data of globex.
+			w := serve(keyA.Key, "", http.MethodGet, "/api/v1/events", "")
+			if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "login") {
+				t.Fatalf("the events of acme got status %d: %s", w.Code, w.Body.String())
+			}
+			if w = serve(keyA.Key, "", http.MethodGet, "/admin/v1/events/login", ""); w.Code != http.StatusNotFound {
+				t.Fatalf("an event of globex read from acme got status %d, want %d", w.Code, http.StatusNotFound)
+			}
+			w = serve(keyA.Key, "", http.MethodGet, "/admin/v1/keys", "")
+			var keys []model.APIKey
+			if err = json.NewDecoder(w.Body).Decode(&keys); err != nil || len(keys) != 1 || keys[0].ID != keyA.ID {
+				t.Fatalf("the keys of acme are %+v, %v, want only its own", keys, err)
+			}
+			for _, request := range []struct{ method, target, body string }{
+				{http.MethodDelete, "/admin/v1/keys/" + strconv.FormatUint(keyB.ID, 10), ""},
+				{http.MethodPost, "/admin/v1/keys/" + strconv.FormatUint(keyB.ID, 10) + "/rotate", ""},
+			} {
+				if w = serve(keyA.Key, "", request.method, request.target, request.body); w.Code != http.StatusNotFound {
+					t.Fatalf("%s %s of a key of globex from acme got status %d, want %d", request.method, request.target, w.Code, http.StatusNotFound)
+				}
+			}
+			serve(keyA.Key, "", http.MethodDelete, "/admin/v1/events/login", "")
+			serve(keyA.Key, "", http.MethodPut, "/admin/v1/retention", `{"days": 1}`)
+			serve(keyA.Key, "", http.MethodDelete, "/admin/v1/retention", "")
+
+			// globex is left as it was, as the admin key of the default project sees through x-project.
+			if w = serve(keyB.Key, "", http.MethodGet, "/api/v1/events", ""); w.Code != http.StatusOK {
+				t.Fatalf("globex can't use its own key, got status %d: %s", w.Code, w.Body.String())
+			}
+			w = serve(testAPIKey, "globex", http.MethodGet, "/admin/v1/event_frequencies/login", "")
+			var eventFreq model.EventFreq
+			if err = json.NewDecoder(w.Body).Decode(&eventFreq); err != nil || eventFreq.TotalCount != 3 {
+				t.Fatalf("the login frequency of globex is %+v, %v, want 3 occurrences", eventFreq, err)
+			}
+			w = serve(testAPIKey, "globex", http.MethodGet, "/admin/v1/retention", "")
+			var settings model.RetentionSettings
+			if err = json.NewDecoder(w.Body).Decode(&settings); err != nil || settings.Days != 30 {
+				t.Fatalf("the retention of globex is %+v, %v, want 30 days", settings, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"net/http"
+	"testing"
+)
+
+func TestEventPropertiesFilterAndGroupBy(t *testing.T) {
+	for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			env, _ := newTestEnv(t, backend)
+			router := newTestRouter(env, http.MethodPost, "/api/v1/events:batch", Env.CreateEventsBatch)
+			router.HandleFunc("/api/v1/events", env.inProject(Env.ReturnEvents)).Methods(http.MethodGet)
+			router.HandleFunc("/admin/v1/event_frequencies/{name}", env.inProject(Env.ReturnEventFrequency)).Methods(http.MethodGet)
+
+			w := serveTestRequest(router, testAPIKey, http.MethodPost, "/api/v1/events:batch", `[
+				{"event": "login", "count": 3, "date": "2021-01-01 10:00:00", "properties": {"platform": "web", "country": "AR"}},
+				{"event": "login", "count": 2, "date": "2021-01-01 11:00:00", "properties": {"country": "AR", "platform": "mobile"}},
+				{"event": "login", "count": 7, "date": "2021-01-02 11:00:00", "properties": {"country": "US", "platform": "mobile"}},
+				{"event": "login", "count": 1, "date": "2021-01-02 11:00:00"}
+			]`)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("recording the batch got status %d: %s", w.Code, w.Body.String())
+			}
+
+			w = serveTestRequest(router, testAPIKey, http.MethodGet, "/api/v1/events?where=country:AR&group_by=platform", "")
+			var events []model.Event
+			if err := json.NewDecoder(w.Body).Decode(&events); err != nil || w.Code != http.StatusOK {
+				t.Fatalf("got status %d, %v", w.Code, err)
+			}
+			counts := map[string]uint64{}
+			for _, event := range events {
+				counts[event.Properties["platform"]] += event.Count
+			}
+			if len(events) != 2 || counts["web"] != 3 || counts["mobile"] != 2 {
+				t.Fatalf("events in AR by platform = %+v, want 3 on web and 2 on mobile", events)
+			}
+
+			w = serveTestRequest(router, testAPIKey, http.MethodGet, "/admin/v1/event_frequencies/login?group_by=country", "")
+			var eventFreqs []model.EventFreq
+			if err := json.NewDecoder(w.Body).Decode(&eventFreqs); err != nil || w.Code != http.StatusOK {
+				t.Fatalf("got status %d, %v", w.Code, err)
+			}
+			byCountry := map[string]model.EventFreq{}
+			for _, eventFreq := range eventFreqs {
+				byCountry[eventFreq.Properties["country"]] = eventFreq
+			}
+			if len(eventFreqs) != 3 || byCountry["AR"].TotalCount != 5 || byCountry["US"].TotalCount != 7 || byCountry[""].TotalCount != 1 {
+				t.Fatalf("frequencies by country = %+v, want 5 in AR, 7 in US and 1 without country", eventFreqs)
+			}
+			if byCountry["AR"].HourCount[10] != 3 || byCountry["AR"].HourCount[11] != 2 {
+				t.Fatalf("hours in AR = %v, want 3 at 10h and 2 at 11h", byCountry["AR"].HourCount)
+			}
+
+			w = serveTestRequest(router, testAPIKey, http.MethodGet, "/api/v1/events?where=country", "")
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("a where without value got status %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"eventTracker/internal/model"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitMiddleware has to run after AuthMiddleware.
+func (env Env) RateLimitMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, ok := apiKeyFromContext(r)
+		if !ok || env.Limiter == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter, err := env.Limiter.Allow(apiKey, time.Now())
+		if err != nil {
+			tooManyRequests(w, retryAfter, err.Error())
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (env Env) reserveIngestion(w http.ResponseWriter, r *http.Request, occurrences uint64) bool {
+	apiKey, ok := apiKeyFromContext(r)
+	if !ok || env.Limiter == nil {
+		return true
+	}
+
+	retryAfter, err := env.Limiter.ReserveIngestion(apiKey, occurrences, time.Now())
+	if err != nil {
+		tooManyRequests(w, retryAfter, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (env Env) releaseIngestion(r *http.Request, occurrences uint64) {
+	apiKey, ok := apiKeyFromContext(r)
+	if !ok || env.Limiter == nil {
+		return
+	}
+
+	env.Limiter.ReleaseIngestion(apiKey, occurrences, time.Now())
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	seconds := math.Max(1, math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+	http.Error(w, message, http.StatusTooManyRequests)
+}
+
+func (env Env) ReturnAPIKeysUsage(w http.ResponseWriter, r *http.Request) {
+	keys, err := env.KeyService.APIKeys(env.APIKeyDBHandler)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	usages := []model.APIKeyUsage{}
+	for _, key := range keys {
+		usages = append(usages, env.keyUsage(key, now))
+	}
+
+	err = json.NewEncoder(w).Encode(usages)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) ReturnAPIKeyUsage(w http.ResponseWriter, r *http.Request) {
+	ID, ok := parseKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := env.KeyService.APIKeys(env.APIKeyDBHandler)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, key := range keys {
+		if key.ID != ID {
+			continue
+		}
+
+		err = json.NewEncoder(w).Encode(env.keyUsage(key, time.Now()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Error(w, fmt.Sprintf(model.ErrAPIKeyNotFound.Error(), ID), http.StatusNotFound)
+}
+
+func (env Env) keyUsage(key model.APIKey, now time.Time) model.APIKeyUsage {
+	if env.Limiter == nil {
+		return model.APIKeyUsage{ID: key.ID, Name: key.Name, Day: now.UTC().Format("2006-01-02"), APIKeyLimits: key.APIKeyLimits}
+	}
+
+	return env.Limiter.Usage(key, now)
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// The retention handlers serve both /retention and /retention/{name}, with an empty name for the global retention.
+
+func (env Env) ReturnRetentions(w http.ResponseWriter, r *http.Request) {
+	settings, err := env.EventService.RetentionSettings(env.EventRetentionDBHandler)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(settings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) SetRetention(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	name := params["name"]
+
+	var body model.RetentionBody
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Json decoder error: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if body.Days == 0 {
+		http.Error(w, "The retention must be of at least 1 day", http.StatusBadRequest)
+		return
+	}
+
+	err = env.EventService.SetRetention(env.EventRetentionDBHandler, name, body.Days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.EventRetention{Name: name, Days: body.Days})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (env Env) DeleteRetention(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	name := params["name"]
+
+	err := env.EventService.DeleteRetention(env.EventRetentionDBHandler, name)
+	if errors.Is(err, model.ErrRetentionNotFound) {
+		target := name
+		if target == "" {
+			target = "all the events"
+		}
+		http.Error(w, fmt.Sprintf(err.Error(), target), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
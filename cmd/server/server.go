@@ -2,51 +2,124 @@ package server
 
 import (
 	"encoding/json"
-	"eventTracker/config"
+	"errors"
+	"eventTracker/internal/auth"
 	"eventTracker/internal/db"
 	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"eventTracker/internal/ratelimit"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type Env struct {
 	EventService event.EventServiceI
 	EventDBHandler db.EventDBHandler
 	EventFreqDBHandler db.EventFreqDBHandler
+	EventIngestHandler db.EventIngestHandler
+	EventPropertyDBHandler db.EventPropertyDBHandler
+	EventOccurrenceDBHandler db.EventOccurrenceDBHandler
+	EventRetentionDBHandler db.EventRetentionDBHandler
+	EventRollupDBHandler db.EventRollupDBHandler
+	EventUniqueDBHandler db.EventUniqueDBHandler
+	EventValueDBHandler db.EventValueDBHandler
+	KeyService auth.KeyServiceI
+	APIKeyDBHandler db.APIKeyDBHandler
+	AlertRuleDBHandler db.AlertRuleDBHandler
+	AlertNotifier event.AlertNotifier
+	AllowInsecureWebhooks bool
+	IdempotencyWindow time.Duration
+	// The stream is disabled when Broker is nil.
+	Broker *event.Broker
+	// No limits apply when Limiter is nil.
+	Limiter *ratelimit.Limiter
 }
 
 func HandleRequests(env Env) {
+	log.Fatal(http.ListenAndServe(":10000", NewRouter(env)))
+}
+
+func NewRouter(env Env) *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
-	router.Use(AuthMiddleware)
+	router.Use(env.MetricsMiddleware)
+	router.Use(env.AuthMiddleware)
+	router.Use(env.RateLimitMiddleware)
 
 	healthRoute := router.PathPrefix("/health").Subrouter()
 	healthRoute.HandleFunc("/ping", pingCheck)
 
+	router.HandleFunc("/metrics", env.inProject(Env.ReturnMetrics)).Methods("GET")
+
 	apiRoute := router.PathPrefix("/api/v1").Subrouter()
 
-	apiRoute.HandleFunc("/events", env.ReturnEvents).Methods("GET")
+	apiRoute.HandleFunc("/events", env.inProject(Env.ReturnEvents)).Methods("GET")
 
-	apiRoute.HandleFunc("/events/{name}", env.CreateEvent).Methods("POST") //N and date in body
+	apiRoute.HandleFunc("/events/stream", env.inProject(Env.StreamEvents)).Methods("GET")
 
-	apiRoute.HandleFunc("/event_history", env.ReturnAllEventsHistory).Methods("GET")
+	apiRoute.HandleFunc("/events/live", env.inProject(Env.StreamLiveCounters)).Methods("GET")
 
-	apiRoute.HandleFunc("/event_frequencies/{name}/hist", env.ReturnEventFrequencyHistogram).Methods("GET")
+	apiRoute.HandleFunc("/events/{name}", env.inProject(Env.CreateEvent)).Methods("POST") //N and date in body
 
-	adminRoute := router.PathPrefix("/admin/v1").Subrouter()
-	adminRoute.HandleFunc("/events/{name}", env.ReturnEvent).Methods("GET")
-	adminRoute.HandleFunc("/events/{name}", env.DeleteEvent).Methods("DELETE")
-	adminRoute.HandleFunc("/event_frequencies/{name}", env.ReturnEventFrequency).Methods("GET")
-	adminRoute.HandleFunc("/event_frequencies", env.ReturnAllEventsFrequencies).Methods("GET")
+	apiRoute.HandleFunc("/events:batch", env.inProject(Env.CreateEventsBatch)).Methods("POST")
+
+	apiRoute.HandleFunc("/write", env.inProject(Env.WriteLineProtocol)).Methods("POST")
+
+	apiRoute.HandleFunc("/events/{name}/series", env.inProject(Env.ReturnEventSeries)).Methods("GET")
+
+	apiRoute.HandleFunc("/events/{name}/uniques", env.inProject(Env.ReturnEventUniques)).Methods("GET")
+
+	apiRoute.HandleFunc("/events/{name}/values", env.inProject(Env.ReturnEventValues)).Methods("GET")
 
-	log.Fatal(http.ListenAndServe(":10000", router))
+	apiRoute.HandleFunc("/funnel", env.inProject(Env.ReturnEventFunnel)).Methods("GET")
+
+	apiRoute.HandleFunc("/event_history", env.inProject(Env.ReturnAllEventsHistory)).Methods("GET")
+
+	apiRoute.HandleFunc("/event_frequencies/{name}/hist", env.inProject(Env.ReturnEventFrequencyHistogram)).Methods("GET")
+	apiRoute.HandleFunc("/event_frequencies/{name}/heatmap", env.inProject(Env.ReturnEventFrequencyHeatmap)).Methods("GET")
+
+	adminRoute := router.PathPrefix("/admin/v1").Subrouter()
+	adminRoute.HandleFunc("/events/{name}", env.inProject(Env.ReturnEvent)).Methods("GET")
+	adminRoute.HandleFunc("/events/{name}", env.inProject(Env.DeleteEvent)).Methods("DELETE")
+	adminRoute.HandleFunc("/events/{name}/anomalies", env.inProject(Env.ReturnEventAnomalies)).Methods("GET")
+	adminRoute.HandleFunc("/event_frequencies/{name}", env.inProject(Env.ReturnEventFrequency)).Methods("GET")
+	adminRoute.HandleFunc("/event_frequencies", env.inProject(Env.ReturnAllEventsFrequencies)).Methods("GET")
+	adminRoute.HandleFunc("/keys", env.inProject(Env.ReturnAPIKeys)).Methods("GET")
+	adminRoute.HandleFunc("/keys", env.inProject(Env.CreateAPIKey)).Methods("POST")
+	adminRoute.HandleFunc("/keys/{id}/rotate", env.inProject(Env.RotateAPIKey)).Methods("POST")
+	adminRoute.HandleFunc("/keys/{id}", env.inProject(Env.RevokeAPIKey)).Methods("DELETE")
+	adminRoute.HandleFunc("/keys/{id}/limits", env.inProject(Env.SetAPIKeyLimits)).Methods("PUT")
+	adminRoute.HandleFunc("/keys/{id}/usage", env.inProject(Env.ReturnAPIKeyUsage)).Methods("GET")
+	adminRoute.HandleFunc("/usage", env.inProject(Env.ReturnAPIKeysUsage)).Methods("GET")
+	adminRoute.HandleFunc("/retention", env.inProject(Env.ReturnRetentions)).Methods("GET")
+	adminRoute.HandleFunc("/retention", env.inProject(Env.SetRetention)).Methods("PUT")
+	adminRoute.HandleFunc("/retention", env.inProject(Env.DeleteRetention)).Methods("DELETE")
+	adminRoute.HandleFunc("/retention/{name}", env.inProject(Env.SetRetention)).Methods("PUT")
+	adminRoute.HandleFunc("/retention/{name}", env.inProject(Env.DeleteRetention)).Methods("DELETE")
+	adminRoute.HandleFunc("/alerts", env.inProject(Env.ReturnAlertRules)).Methods("GET")
+	adminRoute.HandleFunc("/alerts", env.inProject(Env.CreateAlertRule)).Methods("POST")
+	adminRoute.HandleFunc("/alerts/{id}", env.inProject(Env.ReturnAlertRule)).Methods("GET")
+	adminRoute.HandleFunc("/alerts/{id}", env.inProject(Env.UpdateAlertRule)).Methods("PUT")
+	adminRoute.HandleFunc("/alerts/{id}", env.inProject(Env.DeleteAlertRule)).Methods("DELETE")
+	adminRoute.HandleFunc("/alerts/{id}/test", env.inProject(Env.TestAlertRule)).Methods("POST")
+
+	return router
 }
 
-func AuthMiddleware(h http.Handler) http.Handler {
+// The browsers can't set headers on WebSockets, so their handshakes can also send the key in the "api_key" query parameter.
+func (env Env) AuthMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
 		apiKey := r.Header.Get("x-api-key")
+		if authorization := r.Header.Get("Authorization"); apiKey == "" && strings.HasPrefix(authorization, "Bearer ") {
+			apiKey = strings.TrimPrefix(authorization, "Bearer ")
+		}
+		if apiKey == "" && websocket.IsWebSocketUpgrade(r) {
+			apiKey = r.URL.Query().Get("api_key")
+		}
 
 		if apiKey == "" {
 			w.WriteHeader(http.StatusForbidden)
@@ -59,32 +132,54 @@ func AuthMiddleware(h http.Handler) http.Handler {
 			return
 		}
 
-		var apiKeysToCheck []string
-		if strings.Split(r.URL.Path, "/")[1] == "admin" {
-			apiKeysToCheck = config.AdminApiKeys
-		} else {
-			apiKeysToCheck = append(apiKeysToCheck, config.ApiKeys...)
-			apiKeysToCheck = append(apiKeysToCheck, config.AdminApiKeys...)
+		var scope string
+		switch strings.Split(r.URL.Path, "/")[1] {
+		case "admin", "metrics":
+			scope = auth.ScopeAdmin
+		case "api":
+			if r.Method == http.MethodGet {
+				scope = auth.ScopeRead
+			} else {
+				scope = auth.ScopeIngest
+			}
 		}
 
-		authorized := false
-		for _,t := range apiKeysToCheck {
-			if apiKey == t {
-				authorized = true
+		authorizedKey, err := env.KeyService.Authorize(env.APIKeyDBHandler, apiKey, scope, time.Now())
+		if errors.Is(err, model.ErrInvalidAPIKey) {
+			w.WriteHeader(http.StatusForbidden)
+			err = json.NewEncoder(w).Encode("Wrong auth apiKey")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				println(fmt.Sprintf("error: %v", err.Error()))
 			}
+			return
 		}
-
-		if authorized {
-			h.ServeHTTP(w, r)
-		} else {
+		if errors.Is(err, model.ErrMissingScope) {
 			w.WriteHeader(http.StatusForbidden)
-			err := json.NewEncoder(w).Encode("Wrong auth apiKey")
+			err = json.NewEncoder(w).Encode(fmt.Sprintf(err.Error(), scope))
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				println(fmt.Sprintf("error: %v", err.Error()))
-				return
 			}
+			return
 		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		requestedProject := r.Header.Get("x-project")
+		project, err := auth.RequestProject(authorizedKey, requestedProject)
+		if errors.Is(err, model.ErrProjectForbidden) {
+			http.Error(w, fmt.Sprintf(err.Error(), requestedProject), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		h.ServeHTTP(w, withAPIKey(r, authorizedKey, project))
 	})
 }
 
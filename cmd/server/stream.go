@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// streamKeepAlive keeps the proxies from closing the idle streams.
+const streamKeepAlive = 15 * time.Second
+
+// StreamEvents reports the occurrences dropped for a slow client with a "dropped" event.
+func (env Env) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	if env.Broker == nil {
+		http.Error(w, "The live stream is disabled", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "The connection doesn't support streaming", http.StatusInternalServerError)
+		return
+	}
+
+	project, _ := r.Context().Value(projectContextKey).(string)
+	pattern := r.URL.Query().Get("event")
+	subscription, err := env.Broker.Subscribe(project, pattern)
+	if errors.Is(err, model.ErrInvalidEventPattern) {
+		http.Error(w, fmt.Sprintf(err.Error(), pattern), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer env.Broker.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			err = writeDropped(w, subscription.TakeDropped())
+			if err == nil {
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			}
+		case occurrence, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+
+			err = writeDropped(w, subscription.TakeDropped())
+			if err == nil {
+				err = writeServerSentEvent(w, "occurrence", model.StreamedEvent{
+					Name:       occurrence.Name,
+					Count:      occurrence.Count,
+					Date:       occurrence.Date.UTC().Format(time.RFC3339),
+					Properties: occurrence.Properties,
+				})
+			}
+		}
+		if err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeDropped(w http.ResponseWriter, dropped uint64) error {
+	if dropped == 0 {
+		return nil
+	}
+
+	return writeServerSentEvent(w, "dropped", map[string]uint64{"dropped": dropped})
+}
+
+func writeServerSentEvent(w http.ResponseWriter, name string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, encoded)
+	return err
+}
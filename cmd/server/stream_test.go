@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"eventTracker/internal/db"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newStreamTestServer serves the full router of an environment whose ingestion publishes to a broker.
+func newStreamTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	env, _ := newTestEnv(t, db.StorageMemory)
+	env.Broker = event.NewBroker(64)
+	env.EventIngestHandler = event.PublishingIngestHandler{EventIngestHandler: env.EventIngestHandler, Broker: env.Broker, Project: db.DefaultProject}
+
+	server := httptest.NewServer(NewRouter(env))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// postTestEvents records a batch of events on a test server.
+func postTestEvents(t *testing.T, server *httptest.Server, batch string) {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/events:batch", strings.NewReader(batch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("x-api-key", testAPIKey)
+
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("recording %s got status %d", batch, response.StatusCode)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	server := newStreamTestServer(t)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/events/stream?event=login*", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("x-api-key", testAPIKey)
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got status %d and content type %s", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	// The stream is subscribed once its headers are sent, so the occurrences recorded from now on are pushed.
+	postTestEvents(t, server, `[{"event": "login", "count": 2}, {"event": "logout"}, {"event": "login_failed", "count": 3, "properties": {"reason": "password"}}]`)
+	postTestEvents(t, server, `[{"event": "login"}]`)
+
+	want := []model.StreamedEvent{
+		{Name: "login", Count: 2},
+		{Name: "login_failed", Count: 3, Properties: map[string]string{"reason": "password"}},
+		{Name: "login", Count: 1},
+	}
+	reader := bufio.NewReader(response.Body)
+	for i := 0; i < len(want); i++ {
+		var name, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("reading occurrence %d: %v", i, err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" && name != "" {
+				break
+			}
+			if value, ok := strings.CutPrefix(line, "event: "); ok {
+				name = value
+			}
+			if value, ok := strings.CutPrefix(line, "data: "); ok {
+				data = value
+			}
+		}
+
+		var streamed model.StreamedEvent
+		if err = json.Unmarshal([]byte(data), &streamed); err != nil || name != "occurrence" {
+			t.Fatalf("got the %s event %s, %v, want an occurrence", name, data, err)
+		}
+		if streamed.Name != want[i].Name || streamed.Count != want[i].Count || streamed.Properties["reason"] != want[i].Properties["reason"] || streamed.Date == "" {
+			t.Fatalf("occurrence %d = %+v, want %+v", i, streamed, want[i])
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+func (env Env) ReturnEventUniques(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	name := params["name"]
+	queryParams := r.URL.Query()
+
+	startDate, endDate := queryParams.Get("start_date"), queryParams.Get("end_date")
+	for _, param := range []string{"start_date", "end_date"} {
+		_, err := time.Parse("2006-01-02", queryParams.Get(param))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error parsing \"%s\" query parameter: %s", param, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	interval := queryParams.Get("interval")
+	if interval == "" {
+		interval = event.IntervalDay
+	}
+	if interval != event.IntervalDay && interval != event.IntervalWeek && interval != event.IntervalMonth && interval != event.IntervalYear {
+		http.Error(w, fmt.Sprintf("Invalid \"interval\" query parameter %s, must be day, week, month or year", interval), http.StatusBadRequest)
+		return
+	}
+
+	uniques, err := env.EventService.EventUniques(env.EventUniqueDBHandler, name, startDate, endDate, interval)
+	if errors.Is(err, model.ErrInvalidRange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(uniques)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"eventTracker/internal/db"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReturnEventUniques(t *testing.T) {
+	for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			env, storage := newTestEnv(t, backend)
+			router := newTestRouter(env, http.MethodGet, "/api/v1/events/{name}/uniques", Env.ReturnEventUniques)
+
+			// 300 users on the Monday, 300 on the Tuesday of whom 100 were there on Monday, and 50 of them
+			// again the next Monday. The anonymous occurrences and the other events aren't counted.
+			var occurrences []model.EventOccurrence
+			addUsers := func(name string, from, to int, date time.Time) {
+				for i := from; i < to; i++ {
+					occurrences = append(occurrences, model.EventOccurrence{Name: name, Count: 2, Date: date, UserID: "user-" + strconv.Itoa(i)})
+				}
+			}
+			monday := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+			addUsers("login", 0, 300, monday)
+			addUsers("login", 200, 500, monday.AddDate(0, 0, 1))
+			addUsers("login", 0, 50, monday.AddDate(0, 0, 7))
+			addUsers("logout", 1000, 2000, monday)
+			occurrences = append(occurrences, model.EventOccurrence{Name: "login", Count: 10, Date: monday})
+			if err := env.EventService.CreateEvents(storage.EventIngestHandler, occurrences); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, test := range []struct {
+				interval string
+				total    uint64
+				points   map[string]uint64
+			}{
+				{event.IntervalDay, 500, map[string]uint64{"2021-03-01": 300, "2021-03-02": 300, "2021-03-03": 0, "2021-03-08": 50}},
+				{event.IntervalWeek, 500, map[string]uint64{"2021-03-01": 500, "2021-03-08": 50}},
+			} {
+				w := serveTestRequest(router, testAPIKey, http.MethodGet, "/api/v1/events/login/uniques?start_date=2021-03-01&end_date=2021-03-08&interval="+test.interval, "")
+				var uniques model.EventUniques
+				if err := json.NewDecoder(w.Body).Decode(&uniques); err != nil || w.Code != http.StatusOK {
+					t.Fatalf("the %s uniques got status %d, %v", test.interval, w.Code, err)
+				}
+
+				// The estimates are close to exact for a few hundred users.
+				near := func(got, want uint64) bool {
+					return math.Abs(float64(got)-float64(want)) <= math.Max(1, 0.02*float64(want))
+				}
+				if !near(uniques.Uniques, test.total) {
+					t.Fatalf("the %s uniques of login are %d in total, want %d", test.interval, uniques.Uniques, test.total)
+				}
+				found := 0
+				for _, point := range uniques.Points {
+					if want, ok := test.points[point.Date]; ok {
+						found++
+						if !near(point.Uniques, want) {
+							t.Fatalf("the %s uniques of login on %s are %d, want %d", test.interval, point.Date, point.Uniques, want)
+						}
+					}
+				}
+				if found != len(test.points) {
+					t.Fatalf("the %s uniques of login are %+v, want the points %v", test.interval, uniques.Points, test.points)
+				}
+			}
+
+			// Both dates are invalid, and the start date is the one reported.
+			w := serveTestRequest(router, testAPIKey, http.MethodGet, "/api/v1/events/login/uniques?start_date=x&end_date=y", "")
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"start_date"`) {
+				t.Fatalf("got status %d and %q, want %d about start_date", w.Code, w.Body.String(), http.StatusBadRequest)
+			}
+		})
+	}
+}
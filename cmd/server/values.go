@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxPercentiles = 10
+
+func (env Env) ReturnEventValues(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	name := params["name"]
+	queryParams := r.URL.Query()
+
+	location, err := parseLocation(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start, err := parseTimeParam(queryParams.Get("start"), location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing \"start\" query parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+	end, err := parseTimeParam(queryParams.Get("end"), location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing \"end\" query parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	interval := queryParams.Get("interval")
+	if interval == "" {
+		interval = event.IntervalDay
+	}
+	if interval == event.IntervalMinute || !event.ValidInterval(interval) {
+		http.Error(w, fmt.Sprintf(model.ErrInvalidValueInterval.Error(), interval), http.StatusBadRequest)
+		return
+	}
+
+	percentiles, err := parsePercentiles(queryParams.Get("percentiles"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values, err := env.EventService.EventValues(env.EventValueDBHandler, name, start, end, interval, percentiles)
+	if errors.Is(err, model.ErrInvalidRange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(values)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func parsePercentiles(value string) ([]float64, error) {
+	if value == "" {
+		return event.DefaultPercentiles, nil
+	}
+
+	var percentiles []float64
+	for _, field := range strings.Split(value, ",") {
+		percentile, err := strconv.ParseFloat(field, 64)
+		if err != nil || !(percentile >= 0 && percentile <= 100) {
+			return nil, fmt.Errorf("Error parsing \"percentiles\" query parameter: %q must be a number from 0 to 100", field)
+		}
+		percentiles = append(percentiles, percentile)
+	}
+	if len(percentiles) > maxPercentiles {
+		return nil, fmt.Errorf("Error parsing \"percentiles\" query parameter: up to %d percentiles, got %d", maxPercentiles, len(percentiles))
+	}
+
+	return percentiles, nil
+}
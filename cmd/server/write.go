@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"eventTracker/internal/lineprotocol"
+	"eventTracker/internal/model"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WriteLineProtocol rejects the body as a whole when a line is invalid.
+func (env Env) WriteLineProtocol(w http.ResponseWriter, r *http.Request) {
+	precision, err := lineprotocol.Precision(r.URL.Query().Get("precision"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing \"precision\" query parameter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	var body io.ReadCloser = http.MaxBytesReader(w, r.Body, maxBatchBytes)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error reading the gzip body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		defer gzipReader.Close()
+		body = http.MaxBytesReader(w, gzipReader, maxBatchBytes)
+	}
+
+	now := time.Now().UTC()
+	var (
+		occurrences []model.EventOccurrence
+		count       uint64
+		lineNumber  int
+		points      int
+	)
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		points++
+		if points > maxBatchItems {
+			http.Error(w, fmt.Sprintf("The body has more than %d points", maxBatchItems), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		point, e := lineprotocol.ParseLine(line, precision)
+		if e == nil {
+			var occurrence model.EventOccurrence
+			occurrence, e = pointOccurrence(point, now)
+			if e == nil && occurrence.Count > 0 {
+				occurrences = append(occurrences, occurrence)
+				count += occurrence.Count
+			}
+		}
+		if e != nil {
+			http.Error(w, fmt.Sprintf("Error parsing line %d: %s", lineNumber, e.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	var maxBytesErr *http.MaxBytesError
+	if err = scanner.Err(); errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("The body is larger than %d bytes", maxBatchBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading the body: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if len(occurrences) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !env.reserveIngestion(w, r, count) {
+		return
+	}
+
+	err = env.EventService.CreateEvents(env.EventIngestHandler, occurrences)
+	if err != nil {
+		env.releaseIngestion(r, count)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// A point without a "count" field is a single occurrence. The fields other than "count" and "value" are ignored.
+func pointOccurrence(point lineprotocol.Point, now time.Time) (occurrence model.EventOccurrence, err error) {
+	occurrence = model.EventOccurrence{Name: point.Measurement, Count: 1, Date: point.Timestamp, Properties: point.Tags}
+	if occurrence.Date.IsZero() {
+		occurrence.Date = now
+	}
+
+	switch count := point.Fields["count"].(type) {
+	case nil:
+	case int64:
+		if count < 0 {
+			return occurrence, fmt.Errorf("the \"count\" field must not be negative, got %d", count)
+		}
+		occurrence.Count = uint64(count)
+	case uint64:
+		occurrence.Count = count
+	case float64:
+		if count < 0 || count != math.Trunc(count) || count >= math.MaxUint64 {
+			return occurrence, fmt.Errorf("the \"count\" field must be a whole number of occurrences, got %v", count)
+		}
+		occurrence.Count = uint64(count)
+	default:
+		return occurrence, errors.New("the \"count\" field must be a number")
+	}
+
+	var value float64
+	switch fieldValue := point.Fields["value"].(type) {
+	case nil:
+	case int64:
+		value = float64(fieldValue)
+		occurrence.Value = &value
+	case uint64:
+		value = float64(fieldValue)
+		occurrence.Value = &value
+	case float64:
+		if math.IsNaN(fieldValue) || math.IsInf(fieldValue, 0) {
+			return occurrence, fmt.Errorf("the \"value\" field must be a finite number, got %v", fieldValue)
+		}
+		value = fieldValue
+		occurrence.Value = &value
+	default:
+		return occurrence, errors.New("the \"value\" field must be a number")
+	}
+
+	err = validateProperties(occurrence.Properties)
+	if err != nil {
+		return occurrence, err
+	}
+
+	return occurrence, nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"eventTracker/internal/db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteLineProtocolLimits(t *testing.T) {
+	env, storage := newTestEnv(t, db.StorageMemory)
+	router := newTestRouter(env, http.MethodPost, "/api/v1/write", Env.WriteLineProtocol)
+
+	gzipped := func(body string) []byte {
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		if _, err := writer.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.Bytes()
+	}
+
+	// The comments aren't points, so only the size of the body limits them.
+	comments := strings.Repeat("#"+strings.Repeat(" ", 62)+"\n", maxBatchBytes/64+1)
+
+	for _, test := range []struct {
+		name string
+		body []byte
+		gzip bool
+		want int
+	}{
+		{"skipped points count", []byte(strings.Repeat("login count=0i\n", maxBatchItems+1)), false, http.StatusRequestEntityTooLarge},
+		{"large body", []byte(comments), false, http.StatusRequestEntityTooLarge},
+		{"gzip bomb", gzipped(comments), true, http.StatusRequestEntityTooLarge},
+		{"gzip points", gzipped(strings.Repeat("login count=0i\n", maxBatchItems-1) + "login count=2i\n"), true, http.StatusNoContent},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(test.body))
+			r.Header.Set("x-api-key", testAPIKey)
+			if test.gzip {
+				r.Header.Set("Content-Encoding", "gzip")
+			}
+			router.ServeHTTP(w, r)
+
+			if w.Code != test.want {
+				t.Fatalf("got status %d, want %d: %s", w.Code, test.want, w.Body.String())
+			}
+		})
+	}
+
+	eventFreq, err := storage.EventFreqDBHandler.GetEventByName("login")
+	if err != nil || eventFreq.TotalCount != 2 {
+		t.Fatalf("login frequency = %+v, %v, want only the 2 occurrences of the accepted body", eventFreq, err)
+	}
+}
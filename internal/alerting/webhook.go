@@ -0,0 +1,174 @@
+package alerting
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// SignatureHeader is left out for the rules without a secret.
+const SignatureHeader = "X-Event-Tracker-Signature"
+
+// WebhookNotifier retries the deliveries failing with a network error, a 408, a 429 or a 5xx, doubling Backoff each time.
+type WebhookNotifier struct {
+	Client   *http.Client
+	Attempts int
+	Backoff  time.Duration
+}
+
+// Unless insecure, the addresses are checked when dialing, so that a name can't resolve to the internal network.
+func NewWebhookNotifier(insecure bool) *WebhookNotifier {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if !insecure {
+		dialer := &net.Dialer{Timeout: 10 * time.Second, Control: publicAddressControl}
+		transport.DialContext = dialer.DialContext
+	}
+
+	return &WebhookNotifier{Client: &http.Client{Timeout: 10 * time.Second, Transport: transport}, Attempts: 3, Backoff: time.Second}
+}
+
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, e := net.ParseCIDR(cidr)
+	if e != nil {
+		panic(e)
+	}
+	return network
+}
+
+func PublicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateWebhookURL doesn't resolve the names, which are checked when dialing.
+func ValidateWebhookURL(rawURL string, insecure bool) error {
+	webhookURL, e := url.Parse(rawURL)
+	if e != nil || webhookURL.Hostname() == "" {
+		return errors.New(fmt.Sprintf(model.ErrInvalidWebhook.Error(), rawURL))
+	}
+	if insecure {
+		if webhookURL.Scheme != "http" && webhookURL.Scheme != "https" {
+			return errors.New(fmt.Sprintf(model.ErrInvalidWebhook.Error(), rawURL))
+		}
+		return nil
+	}
+	if webhookURL.Scheme != "https" {
+		return errors.New(fmt.Sprintf(model.ErrInvalidWebhook.Error(), rawURL))
+	}
+
+	host := strings.ToLower(webhookURL.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New(fmt.Sprintf(model.ErrPrivateWebhook.Error(), host))
+	}
+	if ip := net.ParseIP(host); ip != nil && !PublicAddress(ip) {
+		return errors.New(fmt.Sprintf(model.ErrPrivateWebhook.Error(), host))
+	}
+
+	return nil
+}
+
+func publicAddressControl(network, address string, conn syscall.RawConn) error {
+	host, _, e := net.SplitHostPort(address)
+	if e != nil {
+		return e
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !PublicAddress(ip) {
+		return errors.New(fmt.Sprintf(model.ErrPrivateWebhook.Error(), host))
+	}
+
+	return nil
+}
+
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (n *WebhookNotifier) Notify(rule model.AlertRule, notification model.AlertNotification) error {
+	body, e := json.Marshal(notification)
+	if e != nil {
+		return e
+	}
+
+	attempts := n.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	backoff := n.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, e := n.post(rule, body)
+		if e == nil {
+			return nil
+		}
+		if !retry || attempt >= attempts {
+			return e
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (n *WebhookNotifier) post(rule model.AlertRule, body []byte) (retry bool, err error) {
+	request, e := http.NewRequest(http.MethodPost, rule.WebhookURL, bytes.NewReader(body))
+	if e != nil {
+		return false, e
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "eventTracker")
+	if rule.Secret != "" {
+		request.Header.Set(SignatureHeader, Sign(rule.Secret, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, e := client.Do(request)
+	if e != nil {
+		return true, e
+	}
+	// Drain the body so that the connection is reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+	_ = response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry = response.StatusCode >= 500 || response.StatusCode == http.StatusRequestTimeout || response.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("the webhook answered %s", response.Status)
+}
@@ -0,0 +1,175 @@
+package alerting
+
+import (
+	"encoding/json"
+	"eventTracker/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookServer answers the deliveries with the given statuses in turn, and then with 204, and keeps
+// the requests it got and when.
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+	times    []time.Time
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		s.times = append(s.times, time.Now())
+
+		status := http.StatusNoContent
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func testNotification() model.AlertNotification {
+	return model.AlertNotification{Project: "default", RuleID: 1, Rule: "no checkouts", Event: "checkout", Condition: "below", Threshold: 1, WindowMinutes: 1440, State: "firing", Time: "2021-01-01T10:00:00Z"}
+}
+
+func TestNotifySignsThePayload(t *testing.T) {
+	server := newWebhookServer(t)
+	notifier := &WebhookNotifier{Client: server.Client(), Attempts: 1}
+
+	notification := testNotification()
+	for _, secret := range []string{"s3cr3t", ""} {
+		rule := model.AlertRule{ID: 1, WebhookURL: server.URL + "/alerts", Secret: secret}
+		if err := notifier.Notify(rule, notification); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(server.requests) != 2 {
+		t.Fatalf("the webhook got %d requests, want 2", len(server.requests))
+	}
+	for i, request := range server.requests {
+		if request.Method != http.MethodPost || request.URL.Path != "/alerts" || request.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("request %d is %s %s of %q, want a JSON POST to /alerts", i, request.Method, request.URL.Path, request.Header.Get("Content-Type"))
+		}
+
+		var payload model.AlertNotification
+		if err := json.Unmarshal(server.bodies[i], &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload != notification {
+			t.Fatalf("the payload is %+v, want %+v", payload, notification)
+		}
+	}
+
+	if signature := server.requests[0].Header.Get(SignatureHeader); signature != Sign("s3cr3t", server.bodies[0]) {
+		t.Fatalf("the signature is %q, want %q", signature, Sign("s3cr3t", server.bodies[0]))
+	}
+	if signature, ok := server.requests[1].Header[SignatureHeader]; ok {
+		t.Fatalf("the notification of a rule without a secret is signed with %q", signature)
+	}
+}
+
+func TestNotifyRetriesWithBackoff(t *testing.T) {
+	server := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	notifier := &WebhookNotifier{Client: server.Client(), Attempts: 3, Backoff: 20 * time.Millisecond}
+
+	if err := notifier.Notify(model.AlertRule{WebhookURL: server.URL}, testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(server.times) != 3 {
+		t.Fatalf("the webhook got %d attempts, want 3", len(server.times))
+	}
+	if gap := server.times[1].Sub(server.times[0]); gap < 20*time.Millisecond {
+		t.Fatalf("the second attempt came %s after the first, want at least 20ms", gap)
+	}
+	if gap := server.times[2].Sub(server.times[1]); gap < 40*time.Millisecond {
+		t.Fatalf("the third attempt came %s after the second, want at least 40ms", gap)
+	}
+}
+
+func TestNotifyGivesUp(t *testing.T) {
+	server := newWebhookServer(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusInternalServerError)
+	notifier := &WebhookNotifier{Client: server.Client(), Attempts: 2, Backoff: time.Millisecond}
+
+	if err := notifier.Notify(model.AlertRule{WebhookURL: server.URL}, testNotification()); err == nil {
+		t.Fatal("the notification was delivered with the webhook failing")
+	}
+	if len(server.requests) != 2 {
+		t.Fatalf("the webhook got %d attempts, want 2", len(server.requests))
+	}
+
+	// The other client errors aren't retried.
+	server = newWebhookServer(t, http.StatusBadRequest)
+	if err := notifier.Notify(model.AlertRule{WebhookURL: server.URL}, testNotification()); err == nil {
+		t.Fatal("the notification was delivered with the webhook answering 400")
+	}
+	if len(server.requests) != 1 {
+		t.Fatalf("the webhook got %d attempts, want 1", len(server.requests))
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	for _, test := range []struct {
+		url      string
+		insecure bool
+		valid    bool
+	}{
+		{"https://hooks.example.com/alerts", false, true},
+		{"https://203.0.113.7/alerts", false, true},
+		{"http://hooks.example.com/alerts", false, false},
+		{"ftp://hooks.example.com", false, false},
+		{"https://", false, false},
+		{"https://localhost/alerts", false, false},
+		{"https://hooks.localhost/alerts", false, false},
+		{"https://127.0.0.1:8080/alerts", false, false},
+		{"https://10.0.0.1/alerts", false, false},
+		{"https://192.168.1.1/alerts", false, false},
+		{"https://169.254.169.254/latest/meta-data", false, false},
+		{"https://[::1]/alerts", false, false},
+		{"https://[fe80::1]/alerts", false, false},
+		{"http://127.0.0.1:8080/alerts", true, true},
+		{"ftp://127.0.0.1", true, false},
+	} {
+		if err := ValidateWebhookURL(test.url, test.insecure); (err == nil) != test.valid {
+			t.Errorf("validating %s with insecure %v got %v, want valid %v", test.url, test.insecure, err, test.valid)
+		}
+	}
+}
+
+func TestWebhookNotifierRefusesPrivateAddresses(t *testing.T) {
+	server := newWebhookServer(t)
+	defer server.Close()
+
+	// The dialer checks the address, whatever name resolves to it.
+	notifier := NewWebhookNotifier(false)
+	notifier.Attempts = 1
+	if err := notifier.Notify(model.AlertRule{WebhookURL: server.URL}, testNotification()); err == nil || len(server.requests) != 0 {
+		t.Fatalf("notifying %s got %v and %d requests, want it refused", server.URL, err, len(server.requests))
+	}
+
+	if err := NewWebhookNotifier(true).Notify(model.AlertRule{WebhookURL: server.URL}, testNotification()); err != nil {
+		t.Fatalf("notifying %s with an insecure notifier got %v", server.URL, err)
+	}
+}
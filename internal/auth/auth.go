@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"fmt"
+	"time"
+)
+
+// The scopes of the API keys. The admin scope includes the other two.
+const (
+	ScopeIngest = "ingest"
+	ScopeRead   = "read"
+	ScopeAdmin  = "admin"
+)
+
+const keyPrefix = "et_"
+
+type KeyServiceI interface {
+	Authorize(APIKeyDBHandler db.APIKeyDBHandler, key, scope string, now time.Time) (apiKey model.APIKey, err error)
+	APIKeys(APIKeyDBHandler db.APIKeyDBHandler) (keys []model.APIKey, err error)
+	CreateAPIKey(APIKeyDBHandler db.APIKeyDBHandler, name string, scopes []string, limits model.APIKeyLimits, expiresAt time.Time, now time.Time) (createdKey model.CreatedAPIKey, err error)
+	RotateAPIKey(APIKeyDBHandler db.APIKeyDBHandler, ID uint64) (rotatedKey model.CreatedAPIKey, err error)
+	RevokeAPIKey(APIKeyDBHandler db.APIKeyDBHandler, ID uint64, now time.Time) (err error)
+	SetAPIKeyLimits(APIKeyDBHandler db.APIKeyDBHandler, ID uint64, limits model.APIKeyLimits) (updatedKey model.APIKey, err error)
+	EnsureAPIKey(APIKeyDBHandler db.APIKeyDBHandler, name, key string, scopes []string, now time.Time) (err error)
+}
+
+type KeyService struct{}
+
+// Authorize returns model.ErrInvalidAPIKey or model.ErrMissingScope. An empty scope is satisfied by any valid key.
+func (ks KeyService) Authorize(APIKeyDBHandler db.APIKeyDBHandler, key, scope string, now time.Time) (apiKey model.APIKey, err error) {
+	apiKey, e := APIKeyDBHandler.GetAPIKeyByHash(hashKey(key))
+	if errors.Is(e, model.ErrAPIKeyNotFound) {
+		return model.APIKey{}, model.ErrInvalidAPIKey
+	}
+	if e != nil {
+		return model.APIKey{}, e
+	}
+
+	if apiKey.RevokedAt != "" {
+		return model.APIKey{}, model.ErrInvalidAPIKey
+	}
+	if apiKey.ExpiresAt != "" {
+		expiresAt, e := time.Parse(time.RFC3339, apiKey.ExpiresAt)
+		if e != nil || !now.Before(expiresAt) {
+			return model.APIKey{}, model.ErrInvalidAPIKey
+		}
+	}
+
+	if scope != "" && !hasScope(apiKey.Scopes, scope) {
+		return model.APIKey{}, model.ErrMissingScope
+	}
+
+	return apiKey, nil
+}
+
+func (ks KeyService) APIKeys(APIKeyDBHandler db.APIKeyDBHandler) (keys []model.APIKey, err error) {
+	keys, e := APIKeyDBHandler.GetAPIKeys()
+	if e != nil {
+		return nil, e
+	}
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+
+	return keys, nil
+}
+
+func (ks KeyService) CreateAPIKey(APIKeyDBHandler db.APIKeyDBHandler, name string, scopes []string, limits model.APIKeyLimits, expiresAt time.Time, now time.Time) (createdKey model.CreatedAPIKey, err error) {
+	var expiry string
+	if !expiresAt.IsZero() {
+		expiry = expiresAt.UTC().Format(time.RFC3339)
+	}
+
+	key, e := generateKey()
+	if e != nil {
+		return model.CreatedAPIKey{}, e
+	}
+
+	apiKey := model.APIKey{Name: name, Prefix: key[:len(keyPrefix)+8], Scopes: scopes, CreatedAt: now.UTC().Format(time.RFC3339), ExpiresAt: expiry, APIKeyLimits: limits}
+	apiKey, e = APIKeyDBHandler.CreateAPIKey(apiKey, hashKey(key))
+	if e != nil {
+		return model.CreatedAPIKey{}, e
+	}
+
+	return model.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (ks KeyService) RotateAPIKey(APIKeyDBHandler db.APIKeyDBHandler, ID uint64) (rotatedKey model.CreatedAPIKey, err error) {
+	key, e := generateKey()
+	if e != nil {
+		return model.CreatedAPIKey{}, e
+	}
+
+	apiKey, e := APIKeyDBHandler.UpdateAPIKeyHash(ID, hashKey(key), key[:len(keyPrefix)+8])
+	if e != nil {
+		return model.CreatedAPIKey{}, e
+	}
+
+	return model.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (ks KeyService) RevokeAPIKey(APIKeyDBHandler db.APIKeyDBHandler, ID uint64, now time.Time) (err error) {
+	return APIKeyDBHandler.RevokeAPIKey(ID, now.UTC().Format(time.RFC3339))
+}
+
+func (ks KeyService) SetAPIKeyLimits(APIKeyDBHandler db.APIKeyDBHandler, ID uint64, limits model.APIKeyLimits) (updatedKey model.APIKey, err error) {
+	return APIKeyDBHandler.UpdateAPIKeyLimits(ID, limits)
+}
+
+// EnsureAPIKey leaves a stored key as it is, even if it was revoked.
+func (ks KeyService) EnsureAPIKey(APIKeyDBHandler db.APIKeyDBHandler, name, key string, scopes []string, now time.Time) (err error) {
+	_, e := APIKeyDBHandler.GetAPIKeyByHash(hashKey(key))
+	if e == nil {
+		return nil
+	}
+	if !errors.Is(e, model.ErrAPIKeyNotFound) {
+		return e
+	}
+
+	prefix := key
+	if len(prefix) > len(keyPrefix)+8 {
+		prefix = prefix[:len(keyPrefix)+8]
+	}
+
+	apiKey := model.APIKey{Name: name, Prefix: prefix, Scopes: scopes, CreatedAt: now.UTC().Format(time.RFC3339)}
+	_, e = APIKeyDBHandler.CreateAPIKey(apiKey, hashKey(key))
+
+	return e
+}
+
+func generateKey() (string, error) {
+	randomBytes := make([]byte, 24)
+	_, e := rand.Read(randomBytes)
+	if e != nil {
+		return "", e
+	}
+
+	return keyPrefix + hex.EncodeToString(randomBytes), nil
+}
+
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateProject(project string) error {
+	if len(project) == 0 || len(project) > 64 {
+		return errors.New(fmt.Sprintf(model.ErrInvalidProject.Error(), project))
+	}
+
+	for _, r := range project {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return errors.New(fmt.Sprintf(model.ErrInvalidProject.Error(), project))
+		}
+	}
+
+	return nil
+}
+
+// Only the admin keys of the default project act on other projects than their own.
+func RequestProject(apiKey model.APIKey, requested string) (project string, err error) {
+	if requested == "" || requested == apiKey.Project {
+		return apiKey.Project, nil
+	}
+
+	e := ValidateProject(requested)
+	if e != nil {
+		return "", e
+	}
+
+	if apiKey.Project != db.DefaultProject || !hasScope(apiKey.Scopes, ScopeAdmin) {
+		return "", model.ErrProjectForbidden
+	}
+
+	return requested, nil
+}
+
+func ValidateScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("the api key must have at least one scope")
+	}
+
+	var validScopes []string
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if scope != ScopeIngest && scope != ScopeRead && scope != ScopeAdmin {
+			return nil, errors.New(fmt.Sprintf(model.ErrInvalidScope.Error(), scope))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			validScopes = append(validScopes, scope)
+		}
+	}
+
+	return validScopes, nil
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+)
+
+type AlertRuleDBHandler interface {
+	// GetAlertProjects returns the projects with an alert rule, whichever the project of the handler.
+	GetAlertProjects() (projects []string, err error)
+	GetAlertRules() (retrievedRules []model.AlertRule, err error)
+	// The rules by ID return model.ErrAlertRuleNotFound, and the names taken model.ErrAlertRuleExists.
+	GetAlertRule(ID uint64) (retrievedRule model.AlertRule, err error)
+	CreateAlertRule(rule model.AlertRule) (createdRule model.AlertRule, err error)
+	// UpdateAlertRule doesn't change the state of the rule.
+	UpdateAlertRule(rule model.AlertRule) (updatedRule model.AlertRule, err error)
+	UpdateAlertRuleState(ID uint64, state string, value uint64, changedAt, evaluatedAt string) (err error)
+	DeleteAlertRule(ID uint64) (err error)
+	ForProject(project string) AlertRuleDBHandler
+}
+
+type AlertRuleDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db AlertRuleDB) ForProject(project string) AlertRuleDBHandler {
+	db.Project = project
+	return db
+}
+
+const alertRuleColumns = "id, project, name, event, condition, threshold, window_minutes, webhook_url, secret, state, value, changed_at, evaluated_at, created_at"
+
+func scanAlertRule(scanner rowScanner) (rule model.AlertRule, err error) {
+	e := scanner.Scan(&rule.ID, &rule.Project, &rule.Name, &rule.Event, &rule.Condition, &rule.Threshold, &rule.WindowMinutes, &rule.WebhookURL,
+		&rule.Secret, &rule.State, &rule.Value, &rule.ChangedAt, &rule.EvaluatedAt, &rule.CreatedAt)
+	if e != nil {
+		return model.AlertRule{}, e
+	}
+
+	return rule, nil
+}
+
+func (db AlertRuleDB) GetAlertProjects() (projects []string, err error) {
+	rows, e := db.Database.Query("SELECT DISTINCT project FROM alertRuleDB ORDER BY project")
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var project string
+
+		e = rows.Scan(&project)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		projects = append(projects, project)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return projects, nil
+}
+
+func (db AlertRuleDB) GetAlertRules() (retrievedRules []model.AlertRule, err error) {
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+alertRuleColumns+" FROM alertRuleDB WHERE project = ? ORDER BY id"), db.Project)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		rule, e := scanAlertRule(rows)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedRules = append(retrievedRules, rule)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedRules, nil
+}
+
+func (db AlertRuleDB) getAlertRule(notFound error, query string, args ...interface{}) (retrievedRule model.AlertRule, err error) {
+	retrievedRule, e := scanAlertRule(db.Database.QueryRow(rebind(db.Backend, query), args...))
+	if e == sql.ErrNoRows {
+		return model.AlertRule{}, notFound
+	}
+	if e != nil {
+		return model.AlertRule{}, e
+	}
+
+	return retrievedRule, nil
+}
+
+func (db AlertRuleDB) GetAlertRule(ID uint64) (retrievedRule model.AlertRule, err error) {
+	return db.getAlertRule(model.ErrAlertRuleNotFound, "SELECT "+alertRuleColumns+" FROM alertRuleDB WHERE id = ? AND project = ?", ID, db.Project)
+}
+
+func (db AlertRuleDB) CreateAlertRule(rule model.AlertRule) (createdRule model.AlertRule, err error) {
+	return db.getAlertRule(model.ErrAlertRuleExists, `INSERT INTO alertRuleDB (project, name, event, condition, threshold, window_minutes, webhook_url, secret, state, value, changed_at, evaluated_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0, '', '', ?) ON CONFLICT (project, name) DO NOTHING RETURNING `+alertRuleColumns,
+		db.Project, rule.Name, rule.Event, rule.Condition, rule.Threshold, rule.WindowMinutes, rule.WebhookURL, rule.Secret, rule.State, rule.CreatedAt)
+}
+
+func (db AlertRuleDB) UpdateAlertRule(rule model.AlertRule) (updatedRule model.AlertRule, err error) {
+	updatedRule, e := db.getAlertRule(model.ErrAlertRuleExists, `UPDATE alertRuleDB SET name = ?, event = ?, condition = ?, threshold = ?, window_minutes = ?, webhook_url = ?, secret = ?
+		WHERE id = ? AND project = ? AND NOT EXISTS (SELECT 1 FROM alertRuleDB WHERE project = ? AND name = ? AND id <> ?) RETURNING `+alertRuleColumns,
+		rule.Name, rule.Event, rule.Condition, rule.Threshold, rule.WindowMinutes, rule.WebhookURL, rule.Secret, rule.ID, db.Project, db.Project, rule.Name, rule.ID)
+	if e == model.ErrAlertRuleExists {
+		// Nothing was updated, either because the name is taken or because there is no such rule.
+		_, e2 := db.GetAlertRule(rule.ID)
+		if e2 != nil {
+			return model.AlertRule{}, e2
+		}
+	}
+	if e != nil {
+		return model.AlertRule{}, e
+	}
+
+	return updatedRule, nil
+}
+
+func (db AlertRuleDB) UpdateAlertRuleState(ID uint64, state string, value uint64, changedAt, evaluatedAt string) (err error) {
+	result, e := db.Database.Exec(rebind(db.Backend, "UPDATE alertRuleDB SET state = ?, value = ?, changed_at = ?, evaluated_at = ? WHERE id = ? AND project = ?"),
+		state, value, changedAt, evaluatedAt, ID, db.Project)
+	if e != nil {
+		return e
+	}
+
+	return alertRuleAffected(result)
+}
+
+func (db AlertRuleDB) DeleteAlertRule(ID uint64) (err error) {
+	result, e := db.Database.Exec(rebind(db.Backend, "DELETE FROM alertRuleDB WHERE id = ? AND project = ?"), ID, db.Project)
+	if e != nil {
+		return e
+	}
+
+	return alertRuleAffected(result)
+}
+
+func alertRuleAffected(result sql.Result) error {
+	affected, e := result.RowsAffected()
+	if e != nil {
+		return e
+	}
+	if affected == 0 {
+		return model.ErrAlertRuleNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+	"strings"
+)
+
+type APIKeyDBHandler interface {
+	GetAPIKeys() (retrievedKeys []model.APIKey, err error)
+	// GetAPIKeyByHash looks up the keys of every project, and returns model.ErrAPIKeyNotFound.
+	GetAPIKeyByHash(keyHash string) (retrievedKey model.APIKey, err error)
+	CreateAPIKey(key model.APIKey, keyHash string) (createdKey model.APIKey, err error)
+	// UpdateAPIKeyHash, RevokeAPIKey and UpdateAPIKeyLimits return model.ErrAPIKeyNotFound.
+	UpdateAPIKeyHash(ID uint64, keyHash, prefix string) (updatedKey model.APIKey, err error)
+	RevokeAPIKey(ID uint64, revokedAt string) (err error)
+	UpdateAPIKeyLimits(ID uint64, limits model.APIKeyLimits) (updatedKey model.APIKey, err error)
+	ForProject(project string) APIKeyDBHandler
+}
+
+type APIKeyDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db APIKeyDB) ForProject(project string) APIKeyDBHandler {
+	db.Project = project
+	return db
+}
+
+const apiKeyColumns = "id, project, name, prefix, scopes, created_at, expires_at, revoked_at, rate_limit, burst, daily_quota"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(scanner rowScanner) (key model.APIKey, err error) {
+	var scopes string
+
+	e := scanner.Scan(&key.ID, &key.Project, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &key.ExpiresAt, &key.RevokedAt,
+		&key.RateLimit, &key.Burst, &key.DailyQuota)
+	if e != nil {
+		return model.APIKey{}, e
+	}
+
+	key.Scopes = strings.Split(scopes, ",")
+
+	return key, nil
+}
+
+func (db APIKeyDB) GetAPIKeys() (retrievedKeys []model.APIKey, err error) {
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+apiKeyColumns+" FROM apiKeyDB WHERE project = ? ORDER BY id"), db.Project)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		key, e := scanAPIKey(rows)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedKeys = append(retrievedKeys, key)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedKeys, nil
+}
+
+func (db APIKeyDB) getAPIKey(query string, args ...interface{}) (retrievedKey model.APIKey, err error) {
+	retrievedKey, e := scanAPIKey(db.Database.QueryRow(rebind(db.Backend, query), args...))
+	if e == sql.ErrNoRows {
+		return model.APIKey{}, model.ErrAPIKeyNotFound
+	}
+	if e != nil {
+		return model.APIKey{}, e
+	}
+
+	return retrievedKey, nil
+}
+
+func (db APIKeyDB) GetAPIKeyByHash(keyHash string) (retrievedKey model.APIKey, err error) {
+	return db.getAPIKey("SELECT "+apiKeyColumns+" FROM apiKeyDB WHERE key_hash = ?", keyHash)
+}
+
+func (db APIKeyDB) CreateAPIKey(key model.APIKey, keyHash string) (createdKey model.APIKey, err error) {
+	return db.getAPIKey(`INSERT INTO apiKeyDB (project, name, key_hash, prefix, scopes, created_at, expires_at, revoked_at, rate_limit, burst, daily_quota)
+		VALUES (?, ?, ?, ?, ?, ?, ?, '', ?, ?, ?) RETURNING `+apiKeyColumns, db.Project, key.Name, keyHash, key.Prefix, strings.Join(key.Scopes, ","), key.CreatedAt, key.ExpiresAt,
+		key.RateLimit, key.Burst, key.DailyQuota)
+}
+
+func (db APIKeyDB) UpdateAPIKeyHash(ID uint64, keyHash, prefix string) (updatedKey model.APIKey, err error) {
+	return db.getAPIKey("UPDATE apiKeyDB SET key_hash = ?, prefix = ? WHERE id = ? AND project = ? AND revoked_at = '' RETURNING "+apiKeyColumns,
+		keyHash, prefix, ID, db.Project)
+}
+
+func (db APIKeyDB) RevokeAPIKey(ID uint64, revokedAt string) (err error) {
+	result, e := db.Database.Exec(rebind(db.Backend, "UPDATE apiKeyDB SET revoked_at = ? WHERE id = ? AND project = ? AND revoked_at = ''"), revokedAt, ID, db.Project)
+	if e != nil {
+		return e
+	}
+
+	revoked, e := result.RowsAffected()
+	if e != nil {
+		return e
+	}
+	if revoked == 0 {
+		_, e = db.getAPIKey("SELECT "+apiKeyColumns+" FROM apiKeyDB WHERE id = ? AND project = ?", ID, db.Project)
+		return e
+	}
+
+	return nil
+}
+
+func (db APIKeyDB) UpdateAPIKeyLimits(ID uint64, limits model.APIKeyLimits) (updatedKey model.APIKey, err error) {
+	return db.getAPIKey("UPDATE apiKeyDB SET rate_limit = ?, burst = ?, daily_quota = ? WHERE id = ? AND project = ? RETURNING "+apiKeyColumns,
+		limits.RateLimit, limits.Burst, limits.DailyQuota, ID, db.Project)
+}
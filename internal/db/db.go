@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"encoding/json"
 	"eventTracker/internal/model"
-	"fmt"
 )
 
 type EventDBHandler interface {
@@ -18,6 +17,7 @@ type EventDBHandler interface {
 	UpdateEvent(ID, count uint64) (err error)
 	DeleteEvents(IDs []uint64) (err error)
 	DeleteEvent(ID uint64) (err error)
+	ForProject(project string) EventDBHandler
 }
 
 type EventFreqDBHandler interface {
@@ -25,21 +25,36 @@ type EventFreqDBHandler interface {
 	GetEventsHistory() (retrievedEvents []model.EventHistory, err error)
 	GetEventByID(ID uint64) (retrievedEvent model.EventFreq, err error)
 	GetEventByName(name string) (retrievedEvent model.EventFreq, err error)
-	CreateEvent(name string, count uint64, hour uint64) (err error)
-	UpdateEvent(ID, count, hour uint64) (err error)
 	DeleteEvent(ID uint64) (err error)
+	ForProject(project string) EventFreqDBHandler
 }
 
 type EventDB struct {
 	Database *sql.DB
+	Backend  string
+	Project  string
 }
 
 type EventFreqDB struct {
 	Database *sql.DB
+	Backend  string
+	Project  string
 }
 
+func (db EventDB) ForProject(project string) EventDBHandler {
+	db.Project = project
+	return db
+}
+
+func (db EventFreqDB) ForProject(project string) EventFreqDBHandler {
+	db.Project = project
+	return db
+}
+
+const eventColumns = "id, date, name, count"
+
 func (db EventDB) GetEvents() (retrievedEvents []model.Event, err error) {
-	rows, e := db.Database.Query("SELECT * FROM eventDB")
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+eventColumns+" FROM eventDB WHERE project = ? ORDER BY id"), db.Project)
 	if e != nil {
 		return nil, e
 	}
@@ -64,7 +79,7 @@ func (db EventDB) GetEvents() (retrievedEvents []model.Event, err error) {
 }
 
 func (db EventDB) GetEventsByName(name string) (retrievedEvents []model.Event, err error) {
-	rows, e := db.Database.Query(fmt.Sprintf("SELECT * FROM eventDB WHERE name LIKE \"%s\"", name))
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+eventColumns+" FROM eventDB WHERE project = ? AND name = ? ORDER BY date"), db.Project, name)
 	if e != nil {
 		return nil, e
 	}
@@ -93,7 +108,7 @@ func (db EventDB) GetEventsByName(name string) (retrievedEvents []model.Event, e
 }
 
 func (db EventDB) GetEventsIDsByName(name string) (retrievedEventsIDs []uint64, err error) {
-	rows, e := db.Database.Query(fmt.Sprintf("SELECT ID FROM eventDB WHERE name LIKE \"%s\"", name))
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT id FROM eventDB WHERE project = ? AND name = ?"), db.Project, name)
 	if e != nil {
 		return nil, e
 	}
@@ -122,7 +137,7 @@ func (db EventDB) GetEventsIDsByName(name string) (retrievedEventsIDs []uint64,
 }
 
 func (db EventDB) GetEventsByDateRange(startDate, endDate string) (retrievedEvents []model.Event, err error) {
-	rows, e := db.Database.Query(fmt.Sprintf("SELECT * FROM eventDB WHERE date BETWEEN \"%s\" and \"%s\"", startDate, endDate))
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+eventColumns+" FROM eventDB WHERE project = ? AND date BETWEEN ? AND ? ORDER BY date"), db.Project, startDate, endDate)
 	if e != nil {
 		return nil, e
 	}
@@ -151,7 +166,7 @@ func (db EventDB) GetEventsByDateRange(startDate, endDate string) (retrievedEven
 }
 
 func (db EventDB) GetEventByNameAndDate(name, date string) (retrievedEvent model.Event, err error) {
-	rows, e := db.Database.Query(fmt.Sprintf("SELECT * FROM eventDB WHERE name LIKE \"%s\" AND date LIKE \"%s\"", name, date))
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+eventColumns+" FROM eventDB WHERE project = ? AND name = ? AND date = ?"), db.Project, name, date)
 	if e != nil {
 		return model.Event{}, e
 	}
@@ -175,7 +190,7 @@ func (db EventDB) GetEventByNameAndDate(name, date string) (retrievedEvent model
 }
 
 func (db EventDB) GetEventByID(ID uint64) (retrievedEvent model.Event, err error) {
-	rows, e := db.Database.Query(fmt.Sprintf("SELECT * FROM eventDB WHERE ID=%d", ID))
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+eventColumns+" FROM eventDB WHERE project = ? AND id = ?"), db.Project, ID)
 	if e != nil {
 		return model.Event{}, e
 	}
@@ -199,12 +214,12 @@ func (db EventDB) GetEventByID(ID uint64) (retrievedEvent model.Event, err error
 }
 
 func (db EventDB) CreateEvent(name string, count uint64, date string) (err error) {
-	stmt, e := db.Database.Prepare("INSERT into eventDB (date, name, count) VALUES (?, ?, ?)")
+	stmt, e := db.Database.Prepare(rebind(db.Backend, upsertEventQuery))
 	if e != nil {
 		return e
 	}
 
-	_, e = stmt.Exec(date, name, count)
+	_, e = stmt.Exec(db.Project, date, name, count)
 	if e != nil {
 		return e
 	}
@@ -218,12 +233,12 @@ func (db EventDB) UpdateEvent(ID, count uint64) (err error) {
 		return e
 	}
 
-	stmt, e := db.Database.Prepare("UPDATE eventDB SET count=? WHERE id=?")
+	stmt, e := db.Database.Prepare(rebind(db.Backend, "UPDATE eventDB SET count=? WHERE id=? AND project=?"))
 	if e != nil {
 		return e
 	}
 
-	_, e = stmt.Exec(event.Count + count, ID)
+	_, e = stmt.Exec(event.Count + count, ID, db.Project)
 	if e != nil {
 		return e
 	}
@@ -242,12 +257,12 @@ func (db EventDB) DeleteEvents(IDs []uint64) (err error) {
 }
 
 func (db EventDB) DeleteEvent(ID uint64) (err error) {
-	stmt, e := db.Database.Prepare("DELETE FROM eventDB WHERE id=?")
+	stmt, e := db.Database.Prepare(rebind(db.Backend, "DELETE FROM eventDB WHERE id=? AND project=?"))
 	if e != nil {
 		return e
 	}
 
-	_, e = stmt.Exec(ID)
+	_, e = stmt.Exec(ID, db.Project)
 	if e != nil {
 		return e
 	}
@@ -255,12 +270,28 @@ func (db EventDB) DeleteEvent(ID uint64) (err error) {
 	return nil
 }
 
+const eventFreqColumns = "id, name, count, hour_count, weekday_count, weekday_hour_count"
+
+func unmarshalEventFreqCounts(event *model.EventFreq, hourCount, weekdayCount, weekdayHourCount string) (err error) {
+	e := json.Unmarshal([]byte(hourCount), &event.HourCount)
+	if e != nil {
+		return e
+	}
+
+	e = json.Unmarshal([]byte(weekdayCount), &event.WeekdayCount)
+	if e != nil {
+		return e
+	}
+
+	return json.Unmarshal([]byte(weekdayHourCount), &event.WeekdayHourCount)
+}
+
 func (db EventFreqDB) GetEvents() (retrievedEvents []model.EventFreq, err error) {
 	var (
 		rows *sql.Rows
 		e error
 	)
-	rows, e = db.Database.Query("SELECT * FROM eventFreqDB")
+	rows, e = db.Database.Query(rebind(db.Backend, "SELECT "+eventFreqColumns+" FROM eventFreqDB WHERE project = ? ORDER BY id"), db.Project)
 	if e != nil {
 		return nil, e
 	}
@@ -269,14 +300,16 @@ func (db EventFreqDB) GetEvents() (retrievedEvents []model.EventFreq, err error)
 		var (
 			event model.EventFreq
 			HourCountString string
+			WeekdayCountString string
+			WeekdayHourCountString string
 		)
 
-		e = rows.Scan(&event.ID, &event.Name, &event.TotalCount, &HourCountString)
+		e = rows.Scan(&event.ID, &event.Name, &event.TotalCount, &HourCountString, &WeekdayCountString, &WeekdayHourCountString)
 		if e != nil {
 			return nil, e
 		}
 
-		e = json.Unmarshal([]byte(HourCountString), &event.HourCount)
+		e = unmarshalEventFreqCounts(&event, HourCountString, WeekdayCountString, WeekdayHourCountString)
 		if e != nil {
 			return nil, e
 		}
@@ -297,7 +330,7 @@ func (db EventFreqDB) GetEventsHistory() (retrievedEvents []model.EventHistory,
 		rows *sql.Rows
 		e error
 	)
-	rows, e = db.Database.Query("SELECT id,name,count FROM eventFreqDB")
+	rows, e = db.Database.Query(rebind(db.Backend, "SELECT id,name,count FROM eventFreqDB WHERE project = ? ORDER BY id"), db.Project)
 	if e != nil {
 		return nil, e
 	}
@@ -322,20 +355,20 @@ func (db EventFreqDB) GetEventsHistory() (retrievedEvents []model.EventHistory,
 }
 
 func (db EventFreqDB) GetEventByID(ID uint64) (retrievedEvent model.EventFreq, err error) {
-	rows, e := db.Database.Query(fmt.Sprintf("SELECT * FROM eventFreqDB WHERE id=%d", ID))
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+eventFreqColumns+" FROM eventFreqDB WHERE project = ? AND id = ?"), db.Project, ID)
 	if e != nil {
 		return model.EventFreq{}, e
 	}
 
 	for rows.Next() {
-		var HourCountString string
+		var HourCountString, WeekdayCountString, WeekdayHourCountString string
 
-		e = rows.Scan(&retrievedEvent.ID, &retrievedEvent.Name, &retrievedEvent.TotalCount, &HourCountString)
+		e = rows.Scan(&retrievedEvent.ID, &retrievedEvent.Name, &retrievedEvent.TotalCount, &HourCountString, &WeekdayCountString, &WeekdayHourCountString)
 		if e != nil {
 			return model.EventFreq{}, e
 		}
 
-		e = json.Unmarshal([]byte(HourCountString), &retrievedEvent.HourCount)
+		e = unmarshalEventFreqCounts(&retrievedEvent, HourCountString, WeekdayCountString, WeekdayHourCountString)
 		if e != nil {
 			return model.EventFreq{}, e
 		}
@@ -353,20 +386,20 @@ func (db EventFreqDB) GetEventByID(ID uint64) (retrievedEvent model.EventFreq, e
 }
 
 func (db EventFreqDB) GetEventByName(name string) (retrievedEvent model.EventFreq, err error) {
-	rows, e := db.Database.Query(fmt.Sprintf("SELECT * FROM eventFreqDB WHERE name LIKE \"%s\"", name))
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT "+eventFreqColumns+" FROM eventFreqDB WHERE project = ? AND name = ?"), db.Project, name)
 	if e != nil {
 		return model.EventFreq{}, e
 	}
 
 	for rows.Next() {
-		var HourCountString string
+		var HourCountString, WeekdayCountString, WeekdayHourCountString string
 
-		e = rows.Scan(&retrievedEvent.ID, &retrievedEvent.Name, &retrievedEvent.TotalCount, &HourCountString)
+		e = rows.Scan(&retrievedEvent.ID, &retrievedEvent.Name, &retrievedEvent.TotalCount, &HourCountString, &WeekdayCountString, &WeekdayHourCountString)
 		if e != nil {
 			return model.EventFreq{}, e
 		}
 
-		e = json.Unmarshal([]byte(HourCountString), &retrievedEvent.HourCount)
+		e = unmarshalEventFreqCounts(&retrievedEvent, HourCountString, WeekdayCountString, WeekdayHourCountString)
 		if e != nil {
 			return model.EventFreq{}, e
 		}
@@ -383,64 +416,13 @@ func (db EventFreqDB) GetEventByName(name string) (retrievedEvent model.EventFre
 	return retrievedEvent, nil
 }
 
-func (db EventFreqDB) CreateEvent(name string, count uint64, hour uint64) (err error) {
-	var hourCount [24]uint64
-	hourCount[hour] = count
-
-	stmt, e := db.Database.Prepare("INSERT into eventFreqDB (name, count, hour_count) VALUES (?, ?, ?)")
-	if e != nil {
-		return e
-	}
-
-	hourCountBytes, e := json.Marshal(hourCount)
-	if e != nil {
-		return e
-	}
-	hourCountString := string(hourCountBytes)
-
-	_, e = stmt.Exec(name, count, hourCountString)
-	if e != nil {
-		return e
-	}
-
-	return nil
-}
-
-func (db EventFreqDB) UpdateEvent(ID, count, hour uint64) (err error) {
-	event, e := db.GetEventByID(ID)
-	if e != nil {
-		return e
-	}
-
-	event.HourCount[hour] += count
-	newTotalCount := event.TotalCount + count
-
-	stmt, e := db.Database.Prepare("UPDATE eventFreqDB SET count=?, hour_count=? where ID=?")
-	if e != nil {
-		return e
-	}
-
-	hourCountBytes, e := json.Marshal(event.HourCount)
-	if e != nil {
-		return e
-	}
-	hourCountString := string(hourCountBytes)
-
-	_, e = stmt.Exec(newTotalCount, hourCountString, ID)
-	if e != nil {
-		return e
-	}
-
-	return nil
-}
-
 func (db EventFreqDB) DeleteEvent(ID uint64) (err error) {
-	stmt, e := db.Database.Prepare("DELETE FROM eventFreqDB where ID=?")
+	stmt, e := db.Database.Prepare(rebind(db.Backend, "DELETE FROM eventFreqDB where ID=? AND project=?"))
 	if e != nil {
 		return e
 	}
 
-	_, e = stmt.Exec(ID)
+	_, e = stmt.Exec(ID, db.Project)
 	if e != nil {
 		return e
 	}
@@ -451,24 +433,24 @@ func (db EventFreqDB) DeleteEvent(ID uint64) (err error) {
 func DBsStartPoint(debug bool) ([]*model.Event, []*model.EventFreq) {
 	if debug {
 		eventList := []*model.Event{
-			{1, "login1", 3, "20210203"},
-			{1, "login1", 6, "20210603"},
-			{1, "login1", 2, "20211207"},
-			{2, "login2", 54, "20210103"},
-			{2, "login2", 43, "20210203"},
-			{2, "login2", 32, "20210223"},
-			{2, "login2", 12, "20211025"},
-			{3, "logout1", 13, "20210809"},
-			{3, "logout1", 8, "20210811"},
-			{4, "logout2", 1, "20211213"},
-			{4, "logout2", 7, "20211223"},
-			{4, "logout2", 2, "20210805"},
+			{ID: 1, Name: "login1", Count: 3, Date: "20210203"},
+			{ID: 1, Name: "login1", Count: 6, Date: "20210603"},
+			{ID: 1, Name: "login1", Count: 2, Date: "20211207"},
+			{ID: 2, Name: "login2", Count: 54, Date: "20210103"},
+			{ID: 2, Name: "login2", Count: 43, Date: "20210203"},
+			{ID: 2, Name: "login2", Count: 32, Date: "20210223"},
+			{ID: 2, Name: "login2", Count: 12, Date: "20211025"},
+			{ID: 3, Name: "logout1", Count: 13, Date: "20210809"},
+			{ID: 3, Name: "logout1", Count: 8, Date: "20210811"},
+			{ID: 4, Name: "logout2", Count: 1, Date: "20211213"},
+			{ID: 4, Name: "logout2", Count: 7, Date: "20211223"},
+			{ID: 4, Name: "logout2", Count: 2, Date: "20210805"},
 		}
 		eventFreqList := []*model.EventFreq{
-			{1, "login1", 11, [24]uint64{0, 0, 0, 1, 2, 0, 3, 0, 3, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
-			{2, "login2", 54 + 43 + 32 + 12, [24]uint64{0, 0, 23, 0, 12, 11, 0, 10, 25, 10, 10, 0, 20, 0, 0, 0, 0, 0, 0, 0, 0, 10, 10, 0}},
-			{3, "logout1", 21, [24]uint64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 10, 10, 1}},
-			{4, "logout2", 10, [24]uint64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 2, 2, 2, 2, 0, 0, 0, 0, 0}},
+			{ID: 1, Name: "login1", TotalCount: 11, HourCount: [24]uint64{0, 0, 0, 1, 2, 0, 3, 0, 3, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
+			{ID: 2, Name: "login2", TotalCount: 54 + 43 + 32 + 12, HourCount: [24]uint64{0, 0, 23, 0, 12, 11, 0, 10, 25, 10, 10, 0, 20, 0, 0, 0, 0, 0, 0, 0, 0, 10, 10, 0}},
+			{ID: 3, Name: "logout1", TotalCount: 21, HourCount: [24]uint64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 10, 10, 1}},
+			{ID: 4, Name: "logout2", TotalCount: 10, HourCount: [24]uint64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 2, 2, 2, 2, 0, 0, 0, 0, 0}},
 		}
 		return eventList, eventFreqList
 	}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"eventTracker/internal/ddsketch"
+	"eventTracker/internal/hll"
+	"eventTracker/internal/model"
+	"fmt"
+	"time"
+)
+
+// EventIngestHandler records each batch of occurrences atomically in every table.
+type EventIngestHandler interface {
+	IngestEvents(occurrences []model.EventOccurrence) (err error)
+	// IngestEventsOnce returns model.ErrDuplicateRequest, or model.ErrIdempotencyKeyReused for a different request,
+	// when the key was already used within the window.
+	IngestEventsOnce(key model.IdempotencyKey, window time.Duration, occurrences []model.EventOccurrence) (err error)
+	ForProject(project string) EventIngestHandler
+}
+
+// EventIngestDB upserts the tables in one transaction, so concurrent ingestions never lose increments.
+type EventIngestDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db EventIngestDB) ForProject(project string) EventIngestHandler {
+	db.Project = project
+	return db
+}
+
+const upsertEventQuery = `INSERT INTO eventDB (project, date, name, count) VALUES (?, ?, ?, ?)
+	ON CONFLICT (project, name, date) DO UPDATE SET count = eventDB.count + excluded.count`
+
+func upsertEventFreqQuery(backend string) string {
+	if backend == StoragePostgres {
+		return `INSERT INTO eventFreqDB (project, name, count, hour_count, weekday_count, weekday_hour_count) VALUES (?, ?, ?, ?::jsonb, ?::jsonb, ?::jsonb)
+			ON CONFLICT (project, name) DO UPDATE SET
+				count = eventFreqDB.count + excluded.count,
+				hour_count = jsonb_set(eventFreqDB.hour_count, ARRAY[?::text], to_jsonb((eventFreqDB.hour_count->>(?::int))::bigint + excluded.count)),
+				weekday_count = jsonb_set(eventFreqDB.weekday_count, ARRAY[?::text], to_jsonb((eventFreqDB.weekday_count->>(?::int))::bigint + excluded.count)),
+				weekday_hour_count = jsonb_set(eventFreqDB.weekday_hour_count, ARRAY[?::text, ?::text], to_jsonb((eventFreqDB.weekday_hour_count->(?::int)->>(?::int))::bigint + excluded.count))`
+	}
+
+	return `INSERT INTO eventFreqDB (project, name, count, hour_count, weekday_count, weekday_hour_count) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (project, name) DO UPDATE SET
+			count = eventFreqDB.count + excluded.count,
+			hour_count = json_set(eventFreqDB.hour_count, ?, json_extract(eventFreqDB.hour_count, ?) + excluded.count),
+			weekday_count = json_set(eventFreqDB.weekday_count, ?, json_extract(eventFreqDB.weekday_count, ?) + excluded.count),
+			weekday_hour_count = json_set(eventFreqDB.weekday_hour_count, ?, json_extract(eventFreqDB.weekday_hour_count, ?) + excluded.count)`
+}
+
+// The buckets to increment are JSON paths for sqlite and array indexes for postgres.
+func eventFreqArgs(backend, project string, occurrence model.EventOccurrence) (args []interface{}, err error) {
+	hour, weekday := occurrence.Date.Hour(), int(occurrence.Date.Weekday())
+
+	var (
+		hourCount        [24]uint64
+		weekdayCount     [7]uint64
+		weekdayHourCount [7][24]uint64
+	)
+	hourCount[hour] = occurrence.Count
+	weekdayCount[weekday] = occurrence.Count
+	weekdayHourCount[weekday][hour] = occurrence.Count
+
+	args = []interface{}{project, occurrence.Name, occurrence.Count}
+	for _, distribution := range []interface{}{hourCount, weekdayCount, weekdayHourCount} {
+		distributionBytes, e := json.Marshal(distribution)
+		if e != nil {
+			return nil, e
+		}
+		args = append(args, string(distributionBytes))
+	}
+
+	if backend == StoragePostgres {
+		return append(args, hour, hour, weekday, weekday, weekday, hour, weekday, hour), nil
+	}
+
+	hourPath := fmt.Sprintf("$[%d]", hour)
+	weekdayPath := fmt.Sprintf("$[%d]", weekday)
+	weekdayHourPath := fmt.Sprintf("$[%d][%d]", weekday, hour)
+	return append(args, hourPath, hourPath, weekdayPath, weekdayPath, weekdayHourPath, weekdayHourPath), nil
+}
+
+func (db EventIngestDB) IngestEvents(occurrences []model.EventOccurrence) (err error) {
+	tx, e := db.Database.Begin()
+	if e != nil {
+		return e
+	}
+
+	e = db.ingest(tx, occurrences)
+	if e != nil {
+		_ = tx.Rollback()
+		return e
+	}
+
+	return tx.Commit()
+}
+
+func (db EventIngestDB) IngestEventsOnce(key model.IdempotencyKey, window time.Duration, occurrences []model.EventOccurrence) (err error) {
+	tx, e := db.Database.Begin()
+	if e != nil {
+		return e
+	}
+
+	_, e = tx.Exec(rebind(db.Backend, "DELETE FROM idempotencyKeyDB WHERE project = ? AND created_at < ?"), db.Project, idempotencyTime(key.CreatedAt.Add(-window)))
+	if e != nil {
+		_ = tx.Rollback()
+		return e
+	}
+
+	result, e := tx.Exec(rebind(db.Backend, `INSERT INTO idempotencyKeyDB (project, idempotency_key, request_hash, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (project, idempotency_key) DO NOTHING`), db.Project, key.Key, key.RequestHash, idempotencyTime(key.CreatedAt))
+	if e != nil {
+		_ = tx.Rollback()
+		return e
+	}
+
+	inserted, e := result.RowsAffected()
+	if e != nil {
+		_ = tx.Rollback()
+		return e
+	}
+
+	if inserted == 0 {
+		var requestHash string
+		e = tx.QueryRow(rebind(db.Backend, "SELECT request_hash FROM idempotencyKeyDB WHERE project = ? AND idempotency_key = ?"), db.Project, key.Key).Scan(&requestHash)
+		_ = tx.Rollback()
+		if e != nil {
+			return e
+		}
+
+		return duplicateRequestError(key, requestHash)
+	}
+
+	e = db.ingest(tx, occurrences)
+	if e != nil {
+		_ = tx.Rollback()
+		return e
+	}
+
+	return tx.Commit()
+}
+
+func (db EventIngestDB) ingest(tx *sql.Tx, occurrences []model.EventOccurrence) (err error) {
+	eventStmt, e := tx.Prepare(rebind(db.Backend, upsertEventQuery))
+	if e != nil {
+		return e
+	}
+
+	eventFreqStmt, e := tx.Prepare(rebind(db.Backend, upsertEventFreqQuery(db.Backend)))
+	if e != nil {
+		return e
+	}
+
+	eventPropertyStmt, e := tx.Prepare(rebind(db.Backend, upsertEventPropertyQuery))
+	if e != nil {
+		return e
+	}
+
+	eventOccurrenceStmt, e := tx.Prepare(rebind(db.Backend, upsertEventOccurrenceQuery))
+	if e != nil {
+		return e
+	}
+
+	eventRollupStmt, e := tx.Prepare(rebind(db.Backend, upsertEventRollupQuery))
+	if e != nil {
+		return e
+	}
+
+	eventUserStmt, e := tx.Prepare(rebind(db.Backend, insertEventUserQuery))
+	if e != nil {
+		return e
+	}
+
+	eventUniqueStmt, e := tx.Prepare(rebind(db.Backend, upsertEventUniqueQuery))
+	if e != nil {
+		return e
+	}
+
+	eventValueStmt, e := tx.Prepare(rebind(db.Backend, upsertEventValueQuery(db.Backend)))
+	if e != nil {
+		return e
+	}
+
+	eventValueBucketStmt, e := tx.Prepare(rebind(db.Backend, upsertEventValueBucketQuery))
+	if e != nil {
+		return e
+	}
+
+	for _, occurrence := range occurrences {
+		hour := occurrence.Date.Hour()
+
+		freqArgs, e := eventFreqArgs(db.Backend, db.Project, occurrence)
+		if e != nil {
+			return e
+		}
+
+		properties, e := encodeProperties(occurrence.Properties)
+		if e != nil {
+			return e
+		}
+
+		date := occurrence.Date.Format("2006-01-02")
+
+		_, e = eventStmt.Exec(db.Project, date, occurrence.Name, occurrence.Count)
+		if e != nil {
+			return e
+		}
+
+		_, e = eventFreqStmt.Exec(freqArgs...)
+		if e != nil {
+			return e
+		}
+
+		_, e = eventPropertyStmt.Exec(db.Project, occurrence.Name, date, hour, properties, occurrence.Count)
+		if e != nil {
+			return e
+		}
+
+		_, e = eventOccurrenceStmt.Exec(db.Project, occurrence.Name, minuteKey(occurrence.Date), occurrence.Count)
+		if e != nil {
+			return e
+		}
+
+		for _, period := range rollupPeriods {
+			_, e = eventRollupStmt.Exec(db.Project, occurrence.Name, period, rollupStart(occurrence.Date, period), occurrence.Count)
+			if e != nil {
+				return e
+			}
+		}
+
+		if occurrence.UserID != "" {
+			_, e = eventUserStmt.Exec(db.Project, occurrence.Name, occurrence.UserID, minuteKey(occurrence.Date))
+			if e != nil {
+				return e
+			}
+
+			register, rank := hll.Position(occurrence.UserID)
+			_, e = eventUniqueStmt.Exec(db.Project, occurrence.Name, occurrence.Date.UTC().Format("2006-01-02"), register, rank)
+			if e != nil {
+				return e
+			}
+		}
+
+		if occurrence.Value != nil {
+			value := *occurrence.Value
+			_, e = eventValueStmt.Exec(db.Project, occurrence.Name, hourKey(occurrence.Date), occurrence.Count, value*float64(occurrence.Count), value, value)
+			if e != nil {
+				return e
+			}
+
+			_, e = eventValueBucketStmt.Exec(db.Project, occurrence.Name, hourKey(occurrence.Date), ddsketch.Key(value), occurrence.Count)
+			if e != nil {
+				return e
+			}
+		}
+	}
+
+	return nil
+}
+
+// idempotencyTime is in UTC and of a fixed width, so that the values sort like the times.
+func idempotencyTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000Z")
+}
+
+func duplicateRequestError(key model.IdempotencyKey, requestHash string) error {
+	if requestHash != key.RequestHash {
+		return model.ErrIdempotencyKeyReused
+	}
+
+	return model.ErrDuplicateRequest
+}
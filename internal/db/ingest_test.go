@@ -0,0 +1,98 @@
+package db
+
+import (
+	"eventTracker/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openTestStorage opens a migrated storage of the backend, on a sqlite file of the test's temporary directory,
+// or on the server of POSTGRES_TEST_DSN for postgres.
+func openTestStorage(t *testing.T, backend string) Storage {
+	t.Helper()
+
+	var (
+		storage Storage
+		err     error
+	)
+	if backend == StoragePostgres {
+		storage = openPostgresTestStorage(t)
+	} else {
+		storage, err = OpenStorage(backend, t.TempDir()+"/events.db", false)
+		if err != nil {
+			t.Fatalf("opening the %s storage: %v", backend, err)
+		}
+		if storage.Database != nil {
+			t.Cleanup(func() { _ = storage.Database.Close() })
+		}
+	}
+
+	if _, err = storage.MigrateUp(); err != nil {
+		t.Fatalf("migrating the %s storage up: %v", backend, err)
+	}
+
+	return storage
+}
+
+func TestConcurrentIngestion(t *testing.T) {
+	const (
+		goroutines = 50
+		batches    = 40
+	)
+
+	for _, backend := range []string{StorageSQLite, StoragePostgres, StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			storage := openTestStorage(t, backend)
+
+			// Every batch records 1 login at 10h and 2 at 15h on 2021-01-01 (a Friday), and 3 at 8h on 2021-01-02.
+			occurrences := []model.EventOccurrence{
+				{Name: "login", Count: 1, Date: time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)},
+				{Name: "login", Count: 2, Date: time.Date(2021, 1, 1, 15, 30, 0, 0, time.UTC)},
+				{Name: "login", Count: 3, Date: time.Date(2021, 1, 2, 8, 0, 0, 0, time.UTC)},
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < goroutines; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < batches; j++ {
+						if err := storage.EventIngestHandler.IngestEvents(occurrences); err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			n := uint64(goroutines * batches)
+
+			events, err := storage.EventDBHandler.GetEventsByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := map[string]uint64{}
+			for _, event := range events {
+				counts[event.Date] += event.Count
+			}
+			if len(events) != 2 || counts["2021-01-01"] != 3*n || counts["2021-01-02"] != 3*n {
+				t.Fatalf("login events = %v, want a row of %d occurrences on each day", events, 3*n)
+			}
+
+			eventFreq, err := storage.EventFreqDBHandler.GetEventByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eventFreq.TotalCount != 6*n || eventFreq.HourCount[8] != 3*n || eventFreq.HourCount[10] != n || eventFreq.HourCount[15] != 2*n {
+				t.Fatalf("login frequency = %+v, want %d occurrences, %d at 8h, %d at 10h and %d at 15h", eventFreq, 6*n, 3*n, n, 2*n)
+			}
+			if eventFreq.WeekdayCount[time.Friday] != 3*n || eventFreq.WeekdayCount[time.Saturday] != 3*n {
+				t.Fatalf("login weekdays = %v, want %d on Friday and Saturday", eventFreq.WeekdayCount, 3*n)
+			}
+		})
+	}
+}
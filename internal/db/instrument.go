@@ -0,0 +1,362 @@
+package db
+
+import (
+	"eventTracker/internal/metrics"
+	"eventTracker/internal/model"
+	"time"
+)
+
+// instrument times the calls of the handlers, as a whole when they run several queries.
+func instrument(storage Storage) Storage {
+	storage.EventDBHandler = instrumentedEventDB{EventDBHandler: storage.EventDBHandler, backend: storage.Backend}
+	storage.EventFreqDBHandler = instrumentedEventFreqDB{EventFreqDBHandler: storage.EventFreqDBHandler, backend: storage.Backend}
+	storage.EventIngestHandler = instrumentedEventIngest{EventIngestHandler: storage.EventIngestHandler, backend: storage.Backend}
+	storage.EventPropertyDBHandler = instrumentedEventPropertyDB{EventPropertyDBHandler: storage.EventPropertyDBHandler, backend: storage.Backend}
+	storage.EventOccurrenceDBHandler = instrumentedEventOccurrenceDB{EventOccurrenceDBHandler: storage.EventOccurrenceDBHandler, backend: storage.Backend}
+	storage.EventRetentionDBHandler = instrumentedEventRetentionDB{EventRetentionDBHandler: storage.EventRetentionDBHandler, backend: storage.Backend}
+	storage.EventRollupDBHandler = instrumentedEventRollupDB{EventRollupDBHandler: storage.EventRollupDBHandler, backend: storage.Backend}
+	storage.EventUniqueDBHandler = instrumentedEventUniqueDB{EventUniqueDBHandler: storage.EventUniqueDBHandler, backend: storage.Backend}
+	storage.EventValueDBHandler = instrumentedEventValueDB{EventValueDBHandler: storage.EventValueDBHandler, backend: storage.Backend}
+	storage.APIKeyDBHandler = instrumentedAPIKeyDB{APIKeyDBHandler: storage.APIKeyDBHandler, backend: storage.Backend}
+	storage.AlertRuleDBHandler = instrumentedAlertRuleDB{AlertRuleDBHandler: storage.AlertRuleDBHandler, backend: storage.Backend}
+
+	return storage
+}
+
+func observeStorage(backend, handler, operation string, start time.Time) {
+	metrics.QueryDuration.WithLabelValues(backend, handler, operation).Observe(time.Since(start).Seconds())
+}
+
+type instrumentedEventDB struct {
+	EventDBHandler
+	backend string
+}
+
+func (db instrumentedEventDB) GetEvents() (events []model.Event, err error) {
+	defer observeStorage(db.backend, "EventDB", "GetEvents", time.Now())
+	return db.EventDBHandler.GetEvents()
+}
+
+func (db instrumentedEventDB) GetEventsByName(name string) (retrievedEvents []model.Event, err error) {
+	defer observeStorage(db.backend, "EventDB", "GetEventsByName", time.Now())
+	return db.EventDBHandler.GetEventsByName(name)
+}
+
+func (db instrumentedEventDB) GetEventsIDsByName(name string) (retrievedEventsIDs []uint64, err error) {
+	defer observeStorage(db.backend, "EventDB", "GetEventsIDsByName", time.Now())
+	return db.EventDBHandler.GetEventsIDsByName(name)
+}
+
+func (db instrumentedEventDB) GetEventsByDateRange(startDate, endDate string) (retrievedEvents []model.Event, err error) {
+	defer observeStorage(db.backend, "EventDB", "GetEventsByDateRange", time.Now())
+	return db.EventDBHandler.GetEventsByDateRange(startDate, endDate)
+}
+
+func (db instrumentedEventDB) GetEventByNameAndDate(name, date string) (retrievedEvent model.Event, err error) {
+	defer observeStorage(db.backend, "EventDB", "GetEventByNameAndDate", time.Now())
+	return db.EventDBHandler.GetEventByNameAndDate(name, date)
+}
+
+func (db instrumentedEventDB) GetEventByID(ID uint64) (retrievedEvent model.Event, err error) {
+	defer observeStorage(db.backend, "EventDB", "GetEventByID", time.Now())
+	return db.EventDBHandler.GetEventByID(ID)
+}
+
+func (db instrumentedEventDB) CreateEvent(name string, count uint64, date string) (err error) {
+	defer observeStorage(db.backend, "EventDB", "CreateEvent", time.Now())
+	return db.EventDBHandler.CreateEvent(name, count, date)
+}
+
+func (db instrumentedEventDB) UpdateEvent(ID, count uint64) (err error) {
+	defer observeStorage(db.backend, "EventDB", "UpdateEvent", time.Now())
+	return db.EventDBHandler.UpdateEvent(ID, count)
+}
+
+func (db instrumentedEventDB) DeleteEvents(IDs []uint64) (err error) {
+	defer observeStorage(db.backend, "EventDB", "DeleteEvents", time.Now())
+	return db.EventDBHandler.DeleteEvents(IDs)
+}
+
+func (db instrumentedEventDB) DeleteEvent(ID uint64) (err error) {
+	defer observeStorage(db.backend, "EventDB", "DeleteEvent", time.Now())
+	return db.EventDBHandler.DeleteEvent(ID)
+}
+
+func (db instrumentedEventDB) ForProject(project string) EventDBHandler {
+	return instrumentedEventDB{EventDBHandler: db.EventDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventFreqDB struct {
+	EventFreqDBHandler
+	backend string
+}
+
+func (db instrumentedEventFreqDB) GetEvents() (retrievedEvents []model.EventFreq, err error) {
+	defer observeStorage(db.backend, "EventFreqDB", "GetEvents", time.Now())
+	return db.EventFreqDBHandler.GetEvents()
+}
+
+func (db instrumentedEventFreqDB) GetEventsHistory() (retrievedEvents []model.EventHistory, err error) {
+	defer observeStorage(db.backend, "EventFreqDB", "GetEventsHistory", time.Now())
+	return db.EventFreqDBHandler.GetEventsHistory()
+}
+
+func (db instrumentedEventFreqDB) GetEventByID(ID uint64) (retrievedEvent model.EventFreq, err error) {
+	defer observeStorage(db.backend, "EventFreqDB", "GetEventByID", time.Now())
+	return db.EventFreqDBHandler.GetEventByID(ID)
+}
+
+func (db instrumentedEventFreqDB) GetEventByName(name string) (retrievedEvent model.EventFreq, err error) {
+	defer observeStorage(db.backend, "EventFreqDB", "GetEventByName", time.Now())
+	return db.EventFreqDBHandler.GetEventByName(name)
+}
+
+func (db instrumentedEventFreqDB) DeleteEvent(ID uint64) (err error) {
+	defer observeStorage(db.backend, "EventFreqDB", "DeleteEvent", time.Now())
+	return db.EventFreqDBHandler.DeleteEvent(ID)
+}
+
+func (db instrumentedEventFreqDB) ForProject(project string) EventFreqDBHandler {
+	return instrumentedEventFreqDB{EventFreqDBHandler: db.EventFreqDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventIngest struct {
+	EventIngestHandler
+	backend string
+}
+
+func (db instrumentedEventIngest) IngestEvents(occurrences []model.EventOccurrence) (err error) {
+	defer observeStorage(db.backend, "EventIngest", "IngestEvents", time.Now())
+	return db.EventIngestHandler.IngestEvents(occurrences)
+}
+
+func (db instrumentedEventIngest) IngestEventsOnce(key model.IdempotencyKey, window time.Duration, occurrences []model.EventOccurrence) (err error) {
+	defer observeStorage(db.backend, "EventIngest", "IngestEventsOnce", time.Now())
+	return db.EventIngestHandler.IngestEventsOnce(key, window, occurrences)
+}
+
+func (db instrumentedEventIngest) ForProject(project string) EventIngestHandler {
+	return instrumentedEventIngest{EventIngestHandler: db.EventIngestHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventPropertyDB struct {
+	EventPropertyDBHandler
+	backend string
+}
+
+func (db instrumentedEventPropertyDB) GetEventProperties(name, startDate, endDate string) (retrievedCounts []model.EventPropertyCount, err error) {
+	defer observeStorage(db.backend, "EventPropertyDB", "GetEventProperties", time.Now())
+	return db.EventPropertyDBHandler.GetEventProperties(name, startDate, endDate)
+}
+
+func (db instrumentedEventPropertyDB) DeleteEventProperties(name string) (err error) {
+	defer observeStorage(db.backend, "EventPropertyDB", "DeleteEventProperties", time.Now())
+	return db.EventPropertyDBHandler.DeleteEventProperties(name)
+}
+
+func (db instrumentedEventPropertyDB) ForProject(project string) EventPropertyDBHandler {
+	return instrumentedEventPropertyDB{EventPropertyDBHandler: db.EventPropertyDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventOccurrenceDB struct {
+	EventOccurrenceDBHandler
+	backend string
+}
+
+func (db instrumentedEventOccurrenceDB) GetEventOccurrences(name string, start, end time.Time) (retrievedCounts []model.EventTimeCount, err error) {
+	defer observeStorage(db.backend, "EventOccurrenceDB", "GetEventOccurrences", time.Now())
+	return db.EventOccurrenceDBHandler.GetEventOccurrences(name, start, end)
+}
+
+func (db instrumentedEventOccurrenceDB) GetEventUsers(names []string, start, end time.Time) (retrievedUsers []model.EventUserTime, err error) {
+	defer observeStorage(db.backend, "EventOccurrenceDB", "GetEventUsers", time.Now())
+	return db.EventOccurrenceDBHandler.GetEventUsers(names, start, end)
+}
+
+func (db instrumentedEventOccurrenceDB) DeleteEventOccurrences(name string) (err error) {
+	defer observeStorage(db.backend, "EventOccurrenceDB", "DeleteEventOccurrences", time.Now())
+	return db.EventOccurrenceDBHandler.DeleteEventOccurrences(name)
+}
+
+func (db instrumentedEventOccurrenceDB) ForProject(project string) EventOccurrenceDBHandler {
+	return instrumentedEventOccurrenceDB{EventOccurrenceDBHandler: db.EventOccurrenceDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventRetentionDB struct {
+	EventRetentionDBHandler
+	backend string
+}
+
+func (db instrumentedEventRetentionDB) GetRetentions() (retentions []model.EventRetention, err error) {
+	defer observeStorage(db.backend, "EventRetentionDB", "GetRetentions", time.Now())
+	return db.EventRetentionDBHandler.GetRetentions()
+}
+
+func (db instrumentedEventRetentionDB) SetRetention(name string, days uint64) (err error) {
+	defer observeStorage(db.backend, "EventRetentionDB", "SetRetention", time.Now())
+	return db.EventRetentionDBHandler.SetRetention(name, days)
+}
+
+func (db instrumentedEventRetentionDB) DeleteRetention(name string) (err error) {
+	defer observeStorage(db.backend, "EventRetentionDB", "DeleteRetention", time.Now())
+	return db.EventRetentionDBHandler.DeleteRetention(name)
+}
+
+func (db instrumentedEventRetentionDB) PruneEvents(name, beforeDate string, limit int) (pruned int, err error) {
+	defer observeStorage(db.backend, "EventRetentionDB", "PruneEvents", time.Now())
+	return db.EventRetentionDBHandler.PruneEvents(name, beforeDate, limit)
+}
+
+func (db instrumentedEventRetentionDB) GetProjects() (projects []string, err error) {
+	defer observeStorage(db.backend, "EventRetentionDB", "GetProjects", time.Now())
+	return db.EventRetentionDBHandler.GetProjects()
+}
+
+func (db instrumentedEventRetentionDB) ForProject(project string) EventRetentionDBHandler {
+	return instrumentedEventRetentionDB{EventRetentionDBHandler: db.EventRetentionDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventRollupDB struct {
+	EventRollupDBHandler
+	backend string
+}
+
+func (db instrumentedEventRollupDB) GetEventRollups(name, period, startDate, endDate string) (retrievedEvents []model.Event, err error) {
+	defer observeStorage(db.backend, "EventRollupDB", "GetEventRollups", time.Now())
+	return db.EventRollupDBHandler.GetEventRollups(name, period, startDate, endDate)
+}
+
+func (db instrumentedEventRollupDB) DeleteEventRollups(name string) (err error) {
+	defer observeStorage(db.backend, "EventRollupDB", "DeleteEventRollups", time.Now())
+	return db.EventRollupDBHandler.DeleteEventRollups(name)
+}
+
+func (db instrumentedEventRollupDB) ForProject(project string) EventRollupDBHandler {
+	return instrumentedEventRollupDB{EventRollupDBHandler: db.EventRollupDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventUniqueDB struct {
+	EventUniqueDBHandler
+	backend string
+}
+
+func (db instrumentedEventUniqueDB) GetEventUniques(name, startDate, endDate string) (retrievedRegisters []model.EventUniqueRegister, err error) {
+	defer observeStorage(db.backend, "EventUniqueDB", "GetEventUniques", time.Now())
+	return db.EventUniqueDBHandler.GetEventUniques(name, startDate, endDate)
+}
+
+func (db instrumentedEventUniqueDB) DeleteEventUniques(name string) (err error) {
+	defer observeStorage(db.backend, "EventUniqueDB", "DeleteEventUniques", time.Now())
+	return db.EventUniqueDBHandler.DeleteEventUniques(name)
+}
+
+func (db instrumentedEventUniqueDB) ForProject(project string) EventUniqueDBHandler {
+	return instrumentedEventUniqueDB{EventUniqueDBHandler: db.EventUniqueDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedEventValueDB struct {
+	EventValueDBHandler
+	backend string
+}
+
+func (db instrumentedEventValueDB) GetEventValues(name string, start, end time.Time) (retrievedStats []model.EventValueStats, err error) {
+	defer observeStorage(db.backend, "EventValueDB", "GetEventValues", time.Now())
+	return db.EventValueDBHandler.GetEventValues(name, start, end)
+}
+
+func (db instrumentedEventValueDB) GetEventValueBuckets(name string, start, end time.Time) (retrievedBuckets []model.EventValueBucket, err error) {
+	defer observeStorage(db.backend, "EventValueDB", "GetEventValueBuckets", time.Now())
+	return db.EventValueDBHandler.GetEventValueBuckets(name, start, end)
+}
+
+func (db instrumentedEventValueDB) DeleteEventValues(name string) (err error) {
+	defer observeStorage(db.backend, "EventValueDB", "DeleteEventValues", time.Now())
+	return db.EventValueDBHandler.DeleteEventValues(name)
+}
+
+func (db instrumentedEventValueDB) ForProject(project string) EventValueDBHandler {
+	return instrumentedEventValueDB{EventValueDBHandler: db.EventValueDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedAPIKeyDB struct {
+	APIKeyDBHandler
+	backend string
+}
+
+func (db instrumentedAPIKeyDB) GetAPIKeys() (retrievedKeys []model.APIKey, err error) {
+	defer observeStorage(db.backend, "APIKeyDB", "GetAPIKeys", time.Now())
+	return db.APIKeyDBHandler.GetAPIKeys()
+}
+
+func (db instrumentedAPIKeyDB) GetAPIKeyByHash(keyHash string) (retrievedKey model.APIKey, err error) {
+	defer observeStorage(db.backend, "APIKeyDB", "GetAPIKeyByHash", time.Now())
+	return db.APIKeyDBHandler.GetAPIKeyByHash(keyHash)
+}
+
+func (db instrumentedAPIKeyDB) CreateAPIKey(key model.APIKey, keyHash string) (createdKey model.APIKey, err error) {
+	defer observeStorage(db.backend, "APIKeyDB", "CreateAPIKey", time.Now())
+	return db.APIKeyDBHandler.CreateAPIKey(key, keyHash)
+}
+
+func (db instrumentedAPIKeyDB) UpdateAPIKeyHash(ID uint64, keyHash, prefix string) (updatedKey model.APIKey, err error) {
+	defer observeStorage(db.backend, "APIKeyDB", "UpdateAPIKeyHash", time.Now())
+	return db.APIKeyDBHandler.UpdateAPIKeyHash(ID, keyHash, prefix)
+}
+
+func (db instrumentedAPIKeyDB) RevokeAPIKey(ID uint64, revokedAt string) (err error) {
+	defer observeStorage(db.backend, "APIKeyDB", "RevokeAPIKey", time.Now())
+	return db.APIKeyDBHandler.RevokeAPIKey(ID, revokedAt)
+}
+
+func (db instrumentedAPIKeyDB) UpdateAPIKeyLimits(ID uint64, limits model.APIKeyLimits) (updatedKey model.APIKey, err error) {
+	defer observeStorage(db.backend, "APIKeyDB", "UpdateAPIKeyLimits", time.Now())
+	return db.APIKeyDBHandler.UpdateAPIKeyLimits(ID, limits)
+}
+
+func (db instrumentedAPIKeyDB) ForProject(project string) APIKeyDBHandler {
+	return instrumentedAPIKeyDB{APIKeyDBHandler: db.APIKeyDBHandler.ForProject(project), backend: db.backend}
+}
+
+type instrumentedAlertRuleDB struct {
+	AlertRuleDBHandler
+	backend string
+}
+
+func (db instrumentedAlertRuleDB) GetAlertProjects() (projects []string, err error) {
+	defer observeStorage(db.backend, "AlertRuleDB", "GetAlertProjects", time.Now())
+	return db.AlertRuleDBHandler.GetAlertProjects()
+}
+
+func (db instrumentedAlertRuleDB) GetAlertRules() (retrievedRules []model.AlertRule, err error) {
+	defer observeStorage(db.backend, "AlertRuleDB", "GetAlertRules", time.Now())
+	return db.AlertRuleDBHandler.GetAlertRules()
+}
+
+func (db instrumentedAlertRuleDB) GetAlertRule(ID uint64) (retrievedRule model.AlertRule, err error) {
+	defer observeStorage(db.backend, "AlertRuleDB", "GetAlertRule", time.Now())
+	return db.AlertRuleDBHandler.GetAlertRule(ID)
+}
+
+func (db instrumentedAlertRuleDB) CreateAlertRule(rule model.AlertRule) (createdRule model.AlertRule, err error) {
+	defer observeStorage(db.backend, "AlertRuleDB", "CreateAlertRule", time.Now())
+	return db.AlertRuleDBHandler.CreateAlertRule(rule)
+}
+
+func (db instrumentedAlertRuleDB) UpdateAlertRule(rule model.AlertRule) (updatedRule model.AlertRule, err error) {
+	defer observeStorage(db.backend, "AlertRuleDB", "UpdateAlertRule", time.Now())
+	return db.AlertRuleDBHandler.UpdateAlertRule(rule)
+}
+
+func (db instrumentedAlertRuleDB) UpdateAlertRuleState(ID uint64, state string, value uint64, changedAt, evaluatedAt string) (err error) {
+	defer observeStorage(db.backend, "AlertRuleDB", "UpdateAlertRuleState", time.Now())
+	return db.AlertRuleDBHandler.UpdateAlertRuleState(ID, state, value, changedAt, evaluatedAt)
+}
+
+func (db instrumentedAlertRuleDB) DeleteAlertRule(ID uint64) (err error) {
+	defer observeStorage(db.backend, "AlertRuleDB", "DeleteAlertRule", time.Now())
+	return db.AlertRuleDBHandler.DeleteAlertRule(ID)
+}
+
+func (db instrumentedAlertRuleDB) ForProject(project string) AlertRuleDBHandler {
+	return instrumentedAlertRuleDB{AlertRuleDBHandler: db.AlertRuleDBHandler.ForProject(project), backend: db.backend}
+}
@@ -0,0 +1,1214 @@
+package db
+
+import (
+	"eventTracker/internal/ddsketch"
+	"eventTracker/internal/hll"
+	"eventTracker/internal/model"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the tables in memory, with a store for each project other than the default one.
+// The API keys and the alert rules of every project are kept in the default store.
+type MemoryStore struct {
+	mu               sync.RWMutex
+	events           map[uint64]model.Event
+	eventIndex       map[string]uint64
+	eventFreqs       map[uint64]model.EventFreq
+	eventProperties  map[string]model.EventPropertyCount
+	eventOccurrences map[string]model.EventTimeCount
+	eventUsers       map[string]model.EventUserTime
+	eventUniques     map[string]model.EventUniqueRegister
+	eventValues      map[string]model.EventValueStats
+	valueBuckets     map[string]model.EventValueBucket
+	retentions       map[string]uint64
+	rollups          map[string]model.Event
+	idempotencyKeys  map[string]model.IdempotencyKey
+	apiKeys          map[uint64]model.APIKey
+	apiKeyHashes     map[string]uint64
+	alertRules       map[uint64]model.AlertRule
+	lastEventID      uint64
+	lastFreqID       uint64
+	lastAPIKeyID     uint64
+	lastAlertRuleID  uint64
+
+	root       *MemoryStore
+	projectsMu sync.Mutex
+	projects   map[string]*MemoryStore
+}
+
+type MemoryEventDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventDB) ForProject(project string) EventDBHandler {
+	return MemoryEventDB{Store: db.Store.project(project)}
+}
+
+type MemoryEventFreqDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventFreqDB) ForProject(project string) EventFreqDBHandler {
+	return MemoryEventFreqDB{Store: db.Store.project(project)}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		events:          map[uint64]model.Event{},
+		eventIndex:      map[string]uint64{},
+		eventFreqs:      map[uint64]model.EventFreq{},
+		eventProperties: map[string]model.EventPropertyCount{},
+
+		eventOccurrences: map[string]model.EventTimeCount{},
+		eventUsers:       map[string]model.EventUserTime{},
+		eventUniques:     map[string]model.EventUniqueRegister{},
+		eventValues:      map[string]model.EventValueStats{},
+		valueBuckets:     map[string]model.EventValueBucket{},
+		retentions:       map[string]uint64{},
+		rollups:          map[string]model.Event{},
+		idempotencyKeys:  map[string]model.IdempotencyKey{},
+		apiKeys:          map[uint64]model.APIKey{},
+		apiKeyHashes:     map[string]uint64{},
+		alertRules:       map[uint64]model.AlertRule{},
+		projects:         map[string]*MemoryStore{},
+	}
+}
+
+func (s *MemoryStore) project(name string) *MemoryStore {
+	root := s
+	if s.root != nil {
+		root = s.root
+	}
+	if name == DefaultProject {
+		return root
+	}
+
+	root.projectsMu.Lock()
+	defer root.projectsMu.Unlock()
+
+	store, ok := root.projects[name]
+	if !ok {
+		store = NewMemoryStore()
+		store.root = root
+		root.projects[name] = store
+	}
+
+	return store
+}
+
+var seedUsers = []string{"user1", "user2", "user3"}
+
+// Seed records the daily counts of DBsStartPoint as occurrences spread over the hours of each event.
+func (s *MemoryStore) Seed() {
+	eventList, eventFreqList := DBsStartPoint(true)
+
+	hourCounts := map[string][24]uint64{}
+	for _, eventFreq := range eventFreqList {
+		hourCounts[eventFreq.Name] = eventFreq.HourCount
+	}
+
+	sort.SliceStable(eventList, func(i, j int) bool {
+		if eventList[i].Name != eventList[j].Name {
+			return eventList[i].Name < eventList[j].Name
+		}
+		return eventList[i].Date < eventList[j].Date
+	})
+
+	var occurrences []model.EventOccurrence
+	for _, event := range eventList {
+		date, e := time.Parse("20060102", event.Date)
+		if e != nil {
+			continue
+		}
+
+		hourCount := hourCounts[event.Name]
+		remaining := event.Count
+		for hour := 0; hour < 24 && remaining > 0; hour++ {
+			count := hourCount[hour]
+			if count > remaining {
+				count = remaining
+			}
+			if count == 0 {
+				continue
+			}
+			hourCount[hour] -= count
+			remaining -= count
+
+			occurrences = append(occurrences, model.EventOccurrence{
+				Name:   event.Name,
+				Count:  count,
+				Date:   date.Add(time.Duration(hour) * time.Hour),
+				UserID: seedUsers[len(occurrences)%len(seedUsers)],
+			})
+		}
+		hourCounts[event.Name] = hourCount
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = MemoryEventIngestDB{Store: s}.ingest(occurrences)
+}
+
+func eventKey(name, date string) string {
+	return name + "\x00" + date
+}
+
+// The caller of putEvent, deleteEvent, ingest, addValue and rollUp must hold the write lock.
+func (s *MemoryStore) putEvent(event model.Event) {
+	s.events[event.ID] = event
+	s.eventIndex[eventKey(event.Name, event.Date)] = event.ID
+}
+
+func (s *MemoryStore) deleteEvent(ID uint64) {
+	event, ok := s.events[ID]
+	if !ok {
+		return
+	}
+
+	delete(s.events, ID)
+	if s.eventIndex[eventKey(event.Name, event.Date)] == ID {
+		delete(s.eventIndex, eventKey(event.Name, event.Date))
+	}
+}
+
+func (s *MemoryStore) filterEvents(keep func(event model.Event) bool) (retrievedEvents []model.Event) {
+	for _, event := range s.events {
+		if keep(event) {
+			retrievedEvents = append(retrievedEvents, event)
+		}
+	}
+
+	sort.Slice(retrievedEvents, func(i, j int) bool { return retrievedEvents[i].ID < retrievedEvents[j].ID })
+
+	return retrievedEvents
+}
+
+func (s *MemoryStore) sortedEventFreqs() (retrievedEvents []model.EventFreq) {
+	for _, eventFreq := range s.eventFreqs {
+		retrievedEvents = append(retrievedEvents, eventFreq)
+	}
+
+	sort.Slice(retrievedEvents, func(i, j int) bool { return retrievedEvents[i].ID < retrievedEvents[j].ID })
+
+	return retrievedEvents
+}
+
+func (db MemoryEventDB) GetEvents() (retrievedEvents []model.Event, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	return db.Store.filterEvents(func(event model.Event) bool { return true }), nil
+}
+
+func (db MemoryEventDB) GetEventsByName(name string) (retrievedEvents []model.Event, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	retrievedEvents = db.Store.filterEvents(func(event model.Event) bool { return event.Name == name })
+	if retrievedEvents == nil {
+		return nil, model.ErrEventNotFound
+	}
+
+	return retrievedEvents, nil
+}
+
+func (db MemoryEventDB) GetEventsIDsByName(name string) (retrievedEventsIDs []uint64, err error) {
+	retrievedEvents, e := db.GetEventsByName(name)
+	if e != nil {
+		return nil, e
+	}
+
+	for _, event := range retrievedEvents {
+		retrievedEventsIDs = append(retrievedEventsIDs, event.ID)
+	}
+
+	return retrievedEventsIDs, nil
+}
+
+func (db MemoryEventDB) GetEventsByDateRange(startDate, endDate string) (retrievedEvents []model.Event, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	retrievedEvents = db.Store.filterEvents(func(event model.Event) bool {
+		return event.Date >= startDate && event.Date <= endDate
+	})
+	if retrievedEvents == nil {
+		return nil, model.ErrEventNotFound
+	}
+
+	return retrievedEvents, nil
+}
+
+func (db MemoryEventDB) GetEventByNameAndDate(name, date string) (retrievedEvent model.Event, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	ID, ok := db.Store.eventIndex[eventKey(name, date)]
+	if !ok {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	return db.Store.events[ID], nil
+}
+
+func (db MemoryEventDB) GetEventByID(ID uint64) (retrievedEvent model.Event, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	retrievedEvent, ok := db.Store.events[ID]
+	if !ok {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	return retrievedEvent, nil
+}
+
+func (db MemoryEventDB) CreateEvent(name string, count uint64, date string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	db.Store.lastEventID++
+	db.Store.putEvent(model.Event{ID: db.Store.lastEventID, Name: name, Count: count, Date: date})
+
+	return nil
+}
+
+func (db MemoryEventDB) UpdateEvent(ID, count uint64) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	event, ok := db.Store.events[ID]
+	if !ok {
+		return model.ErrEventNotFound
+	}
+
+	event.Count += count
+	db.Store.putEvent(event)
+
+	return nil
+}
+
+func (db MemoryEventDB) DeleteEvents(IDs []uint64) (err error) {
+	for _, ID := range IDs {
+		e := db.DeleteEvent(ID)
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (db MemoryEventDB) DeleteEvent(ID uint64) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	db.Store.deleteEvent(ID)
+
+	return nil
+}
+
+func (db MemoryEventFreqDB) GetEvents() (retrievedEvents []model.EventFreq, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	return db.Store.sortedEventFreqs(), nil
+}
+
+func (db MemoryEventFreqDB) GetEventsHistory() (retrievedEvents []model.EventHistory, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, eventFreq := range db.Store.sortedEventFreqs() {
+		retrievedEvents = append(retrievedEvents, model.EventHistory{ID: eventFreq.ID, Name: eventFreq.Name, TotalCount: eventFreq.TotalCount})
+	}
+
+	return retrievedEvents, nil
+}
+
+func (db MemoryEventFreqDB) GetEventByID(ID uint64) (retrievedEvent model.EventFreq, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	retrievedEvent, ok := db.Store.eventFreqs[ID]
+	if !ok {
+		return model.EventFreq{}, model.ErrEventNotFound
+	}
+
+	return retrievedEvent, nil
+}
+
+func (db MemoryEventFreqDB) GetEventByName(name string) (retrievedEvent model.EventFreq, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, eventFreq := range db.Store.eventFreqs {
+		if eventFreq.Name == name {
+			return eventFreq, nil
+		}
+	}
+
+	return model.EventFreq{}, model.ErrEventNotFound
+}
+
+func (db MemoryEventFreqDB) DeleteEvent(ID uint64) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	delete(db.Store.eventFreqs, ID)
+
+	return nil
+}
+
+type MemoryEventIngestDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventIngestDB) ForProject(project string) EventIngestHandler {
+	return MemoryEventIngestDB{Store: db.Store.project(project)}
+}
+
+func (db MemoryEventIngestDB) IngestEvents(occurrences []model.EventOccurrence) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	return db.ingest(occurrences)
+}
+
+func (db MemoryEventIngestDB) IngestEventsOnce(key model.IdempotencyKey, window time.Duration, occurrences []model.EventOccurrence) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	expiry := key.CreatedAt.Add(-window)
+	for k, storedKey := range db.Store.idempotencyKeys {
+		if storedKey.CreatedAt.Before(expiry) {
+			delete(db.Store.idempotencyKeys, k)
+		}
+	}
+
+	if storedKey, ok := db.Store.idempotencyKeys[key.Key]; ok {
+		return duplicateRequestError(key, storedKey.RequestHash)
+	}
+
+	e := db.ingest(occurrences)
+	if e != nil {
+		return e
+	}
+
+	db.Store.idempotencyKeys[key.Key] = key
+
+	return nil
+}
+
+func (db MemoryEventIngestDB) ingest(occurrences []model.EventOccurrence) (err error) {
+	for _, occurrence := range occurrences {
+		properties, e := encodeProperties(occurrence.Properties)
+		if e != nil {
+			return e
+		}
+
+		date := occurrence.Date.Format("2006-01-02")
+		hour := uint64(occurrence.Date.Hour())
+
+		db.Store.ingest(occurrence.Name, occurrence.Count, date, hour, uint64(occurrence.Date.Weekday()))
+
+		key := fmt.Sprintf("%s\x00%s\x00%d\x00%s", occurrence.Name, date, hour, properties)
+		count, ok := db.Store.eventProperties[key]
+		if !ok {
+			propertiesCopy := map[string]string{}
+			for k, v := range occurrence.Properties {
+				propertiesCopy[k] = v
+			}
+			count = model.EventPropertyCount{Name: occurrence.Name, Date: date, Hour: hour, Properties: propertiesCopy}
+		}
+		count.Count += occurrence.Count
+		db.Store.eventProperties[key] = count
+
+		minute := occurrence.Date.UTC().Truncate(time.Minute)
+		key = occurrence.Name + "\x00" + minuteKey(minute)
+		timeCount, ok := db.Store.eventOccurrences[key]
+		if !ok {
+			timeCount = model.EventTimeCount{Name: occurrence.Name, Time: minute}
+		}
+		timeCount.Count += occurrence.Count
+		db.Store.eventOccurrences[key] = timeCount
+
+		if occurrence.UserID != "" {
+			key = occurrence.Name + "\x00" + minuteKey(minute) + "\x00" + occurrence.UserID
+			db.Store.eventUsers[key] = model.EventUserTime{Name: occurrence.Name, UserID: occurrence.UserID, Time: minute}
+
+			register, rank := hll.Position(occurrence.UserID)
+			unique := model.EventUniqueRegister{Name: occurrence.Name, Date: minute.Format("2006-01-02"), Register: register, Rank: rank}
+			key = fmt.Sprintf("%s\x00%s\x00%d", unique.Name, unique.Date, unique.Register)
+			if rank > db.Store.eventUniques[key].Rank {
+				db.Store.eventUniques[key] = unique
+			}
+		}
+
+		if occurrence.Value != nil {
+			db.Store.addValue(occurrence.Name, occurrence.Date.UTC().Truncate(time.Hour), *occurrence.Value, occurrence.Count)
+		}
+
+		db.Store.rollUp(occurrence.Name, occurrence.Count, occurrence.Date)
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) ingest(name string, count uint64, date string, hour, weekday uint64) {
+	if ID, ok := s.eventIndex[eventKey(name, date)]; ok {
+		event := s.events[ID]
+		event.Count += count
+		s.events[ID] = event
+	} else {
+		s.lastEventID++
+		s.putEvent(model.Event{ID: s.lastEventID, Name: name, Count: count, Date: date})
+	}
+
+	for ID, eventFreq := range s.eventFreqs {
+		if eventFreq.Name == name {
+			eventFreq.TotalCount += count
+			eventFreq.HourCount[hour] += count
+			eventFreq.WeekdayCount[weekday] += count
+			eventFreq.WeekdayHourCount[weekday][hour] += count
+			s.eventFreqs[ID] = eventFreq
+			return
+		}
+	}
+
+	eventFreq := model.EventFreq{Name: name, TotalCount: count}
+	eventFreq.HourCount[hour] = count
+	eventFreq.WeekdayCount[weekday] = count
+	eventFreq.WeekdayHourCount[weekday][hour] = count
+
+	s.lastFreqID++
+	eventFreq.ID = s.lastFreqID
+	s.eventFreqs[s.lastFreqID] = eventFreq
+}
+
+func (s *MemoryStore) addValue(name string, hour time.Time, value float64, count uint64) {
+	key := name + "\x00" + minuteKey(hour)
+	stats, ok := s.eventValues[key]
+	if !ok {
+		stats = model.EventValueStats{Name: name, Time: hour, Min: value, Max: value}
+	}
+	stats.Count += count
+	stats.Total += value * float64(count)
+	stats.Min = math.Min(stats.Min, value)
+	stats.Max = math.Max(stats.Max, value)
+	s.eventValues[key] = stats
+
+	bucketKey := ddsketch.Key(value)
+	key = fmt.Sprintf("%s\x00%s\x00%d", name, minuteKey(hour), bucketKey)
+	bucket, ok := s.valueBuckets[key]
+	if !ok {
+		bucket = model.EventValueBucket{Name: name, Time: hour, Bucket: bucketKey}
+	}
+	bucket.Count += count
+	s.valueBuckets[key] = bucket
+}
+
+func (s *MemoryStore) rollUp(name string, count uint64, date time.Time) {
+	for _, period := range rollupPeriods {
+		start := rollupStart(date, period)
+		key := name + "\x00" + period + "\x00" + start
+		rollup, ok := s.rollups[key]
+		if !ok {
+			rollup = model.Event{Name: name, Date: start}
+		}
+		rollup.Count += count
+		s.rollups[key] = rollup
+	}
+}
+
+type MemoryEventPropertyDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventPropertyDB) ForProject(project string) EventPropertyDBHandler {
+	return MemoryEventPropertyDB{Store: db.Store.project(project)}
+}
+
+func (db MemoryEventPropertyDB) GetEventProperties(name, startDate, endDate string) (retrievedCounts []model.EventPropertyCount, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, count := range db.Store.eventProperties {
+		if name != "" && count.Name != name {
+			continue
+		}
+		if (startDate != "" && count.Date < startDate) || (endDate != "" && count.Date > endDate) {
+			continue
+		}
+
+		retrievedCounts = append(retrievedCounts, count)
+	}
+
+	sort.Slice(retrievedCounts, func(i, j int) bool {
+		a, b := retrievedCounts[i], retrievedCounts[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return a.Hour < b.Hour
+	})
+
+	return retrievedCounts, nil
+}
+
+func (db MemoryEventPropertyDB) DeleteEventProperties(name string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	for key, count := range db.Store.eventProperties {
+		if count.Name == name {
+			delete(db.Store.eventProperties, key)
+		}
+	}
+
+	return nil
+}
+
+type MemoryEventOccurrenceDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventOccurrenceDB) ForProject(project string) EventOccurrenceDBHandler {
+	return MemoryEventOccurrenceDB{Store: db.Store.project(project)}
+}
+
+func (db MemoryEventOccurrenceDB) GetEventOccurrences(name string, start, end time.Time) (retrievedCounts []model.EventTimeCount, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	start, end = start.UTC().Truncate(time.Minute), end.UTC().Truncate(time.Minute)
+	for _, count := range db.Store.eventOccurrences {
+		if name != "" && count.Name != name {
+			continue
+		}
+		if (!start.IsZero() && count.Time.Before(start)) || (!end.IsZero() && !count.Time.Before(end)) {
+			continue
+		}
+
+		retrievedCounts = append(retrievedCounts, count)
+	}
+
+	sort.Slice(retrievedCounts, func(i, j int) bool {
+		if retrievedCounts[i].Name != retrievedCounts[j].Name {
+			return retrievedCounts[i].Name < retrievedCounts[j].Name
+		}
+		return retrievedCounts[i].Time.Before(retrievedCounts[j].Time)
+	})
+
+	return retrievedCounts, nil
+}
+
+func (db MemoryEventOccurrenceDB) GetEventUsers(names []string, start, end time.Time) (retrievedUsers []model.EventUserTime, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	inNames := map[string]bool{}
+	for _, name := range names {
+		inNames[name] = true
+	}
+
+	start, end = start.UTC().Truncate(time.Minute), end.UTC().Truncate(time.Minute)
+	for _, user := range db.Store.eventUsers {
+		if inNames[user.Name] && !user.Time.Before(start) && user.Time.Before(end) {
+			retrievedUsers = append(retrievedUsers, user)
+		}
+	}
+
+	sort.Slice(retrievedUsers, func(i, j int) bool {
+		if retrievedUsers[i].UserID != retrievedUsers[j].UserID {
+			return retrievedUsers[i].UserID < retrievedUsers[j].UserID
+		}
+		return retrievedUsers[i].Time.Before(retrievedUsers[j].Time)
+	})
+
+	return retrievedUsers, nil
+}
+
+func (db MemoryEventOccurrenceDB) DeleteEventOccurrences(name string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	for key, count := range db.Store.eventOccurrences {
+		if count.Name == name {
+			delete(db.Store.eventOccurrences, key)
+		}
+	}
+
+	for key, user := range db.Store.eventUsers {
+		if user.Name == name {
+			delete(db.Store.eventUsers, key)
+		}
+	}
+
+	return nil
+}
+
+type MemoryEventRetentionDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventRetentionDB) ForProject(project string) EventRetentionDBHandler {
+	return MemoryEventRetentionDB{Store: db.Store.project(project)}
+}
+
+func (db MemoryEventRetentionDB) GetProjects() (projects []string, err error) {
+	root := db.Store.project(DefaultProject)
+
+	stores := map[string]*MemoryStore{DefaultProject: root}
+	root.projectsMu.Lock()
+	for name, store := range root.projects {
+		stores[name] = store
+	}
+	root.projectsMu.Unlock()
+
+	for name, store := range stores {
+		store.mu.RLock()
+		if len(store.eventFreqs) > 0 || len(store.retentions) > 0 {
+			projects = append(projects, name)
+		}
+		store.mu.RUnlock()
+	}
+
+	sort.Strings(projects)
+
+	return projects, nil
+}
+
+func (db MemoryEventRetentionDB) GetRetentions() (retentions []model.EventRetention, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for name, days := range db.Store.retentions {
+		retentions = append(retentions, model.EventRetention{Name: name, Days: days})
+	}
+
+	sort.Slice(retentions, func(i, j int) bool { return retentions[i].Name < retentions[j].Name })
+
+	return retentions, nil
+}
+
+func (db MemoryEventRetentionDB) SetRetention(name string, days uint64) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	db.Store.retentions[name] = days
+
+	return nil
+}
+
+func (db MemoryEventRetentionDB) DeleteRetention(name string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	if _, ok := db.Store.retentions[name]; !ok {
+		return model.ErrRetentionNotFound
+	}
+	delete(db.Store.retentions, name)
+
+	return nil
+}
+
+func (db MemoryEventRetentionDB) PruneEvents(name, beforeDate string, limit int) (pruned int, err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	expiredEvents := db.Store.filterEvents(func(event model.Event) bool {
+		return event.Name == name && event.Date < beforeDate
+	})
+	if len(expiredEvents) == 0 {
+		return 0, nil
+	}
+
+	sort.Slice(expiredEvents, func(i, j int) bool { return expiredEvents[i].Date < expiredEvents[j].Date })
+	if len(expiredEvents) > limit {
+		expiredEvents = expiredEvents[:limit]
+	}
+
+	var totalCount uint64
+	for _, event := range expiredEvents {
+		totalCount += event.Count
+		db.Store.deleteEvent(event.ID)
+	}
+
+	for key, count := range rollupCounts(expiredEvents) {
+		rollupKey := name + "\x00" + key.period + "\x00" + key.start
+		rollup, ok := db.Store.rollups[rollupKey]
+		if !ok {
+			continue
+		}
+		if rollup.Count <= count {
+			delete(db.Store.rollups, rollupKey)
+			continue
+		}
+		rollup.Count -= count
+		db.Store.rollups[rollupKey] = rollup
+	}
+	lastDate := expiredEvents[len(expiredEvents)-1].Date
+
+	var hourlyCounts []model.EventPropertyCount
+	for key, count := range db.Store.eventProperties {
+		if count.Name == name && count.Date <= lastDate {
+			hourlyCounts = append(hourlyCounts, count)
+			delete(db.Store.eventProperties, key)
+		}
+	}
+
+	for ID, eventFreq := range db.Store.eventFreqs {
+		if eventFreq.Name == name {
+			subtractEventFreqCounts(&eventFreq, totalCount, hourlyCounts)
+			db.Store.eventFreqs[ID] = eventFreq
+			break
+		}
+	}
+
+	nextDay, e := time.Parse("2006-01-02", lastDate)
+	if e != nil {
+		return 0, e
+	}
+	nextDay = nextDay.AddDate(0, 0, 1)
+	for key, count := range db.Store.eventOccurrences {
+		if count.Name == name && count.Time.Before(nextDay) {
+			delete(db.Store.eventOccurrences, key)
+		}
+	}
+
+	for key, user := range db.Store.eventUsers {
+		if user.Name == name && user.Time.Before(nextDay) {
+			delete(db.Store.eventUsers, key)
+		}
+	}
+
+	for key, unique := range db.Store.eventUniques {
+		if unique.Name == name && unique.Date <= lastDate {
+			delete(db.Store.eventUniques, key)
+		}
+	}
+
+	for key, stats := range db.Store.eventValues {
+		if stats.Name == name && stats.Time.Before(nextDay) {
+			delete(db.Store.eventValues, key)
+		}
+	}
+
+	for key, bucket := range db.Store.valueBuckets {
+		if bucket.Name == name && bucket.Time.Before(nextDay) {
+			delete(db.Store.valueBuckets, key)
+		}
+	}
+
+	return len(expiredEvents), nil
+}
+
+type MemoryEventRollupDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventRollupDB) ForProject(project string) EventRollupDBHandler {
+	return MemoryEventRollupDB{Store: db.Store.project(project)}
+}
+
+func (db MemoryEventRollupDB) GetEventRollups(name, period, startDate, endDate string) (retrievedEvents []model.Event, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	keep := func(event model.Event) bool {
+		return (name == "" || event.Name == name) && (startDate == "" || event.Date >= startDate) && (endDate == "" || event.Date <= endDate)
+	}
+
+	if period == RollupDay {
+		for _, event := range db.Store.filterEvents(keep) {
+			retrievedEvents = append(retrievedEvents, model.Event{Name: event.Name, Date: event.Date, Count: event.Count})
+		}
+	} else {
+		for key, rollup := range db.Store.rollups {
+			if strings.HasPrefix(key, rollup.Name+"\x00"+period+"\x00") && keep(rollup) {
+				retrievedEvents = append(retrievedEvents, rollup)
+			}
+		}
+	}
+
+	sort.Slice(retrievedEvents, func(i, j int) bool {
+		if retrievedEvents[i].Date != retrievedEvents[j].Date {
+			return retrievedEvents[i].Date < retrievedEvents[j].Date
+		}
+		return retrievedEvents[i].Name < retrievedEvents[j].Name
+	})
+
+	return retrievedEvents, nil
+}
+
+func (db MemoryEventRollupDB) DeleteEventRollups(name string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	for key, rollup := range db.Store.rollups {
+		if rollup.Name == name {
+			delete(db.Store.rollups, key)
+		}
+	}
+
+	return nil
+}
+
+type MemoryEventUniqueDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventUniqueDB) ForProject(project string) EventUniqueDBHandler {
+	return MemoryEventUniqueDB{Store: db.Store.project(project)}
+}
+
+func (db MemoryEventUniqueDB) GetEventUniques(name, startDate, endDate string) (retrievedRegisters []model.EventUniqueRegister, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, unique := range db.Store.eventUniques {
+		if (name == "" || unique.Name == name) && (startDate == "" || unique.Date >= startDate) && (endDate == "" || unique.Date <= endDate) {
+			retrievedRegisters = append(retrievedRegisters, unique)
+		}
+	}
+
+	sort.Slice(retrievedRegisters, func(i, j int) bool {
+		if retrievedRegisters[i].Name != retrievedRegisters[j].Name {
+			return retrievedRegisters[i].Name < retrievedRegisters[j].Name
+		}
+		return retrievedRegisters[i].Date < retrievedRegisters[j].Date
+	})
+
+	return retrievedRegisters, nil
+}
+
+func (db MemoryEventUniqueDB) DeleteEventUniques(name string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	for key, unique := range db.Store.eventUniques {
+		if unique.Name == name {
+			delete(db.Store.eventUniques, key)
+		}
+	}
+
+	return nil
+}
+
+type MemoryEventValueDB struct {
+	Store *MemoryStore
+}
+
+func (db MemoryEventValueDB) ForProject(project string) EventValueDBHandler {
+	return MemoryEventValueDB{Store: db.Store.project(project)}
+}
+
+func (db MemoryEventValueDB) GetEventValues(name string, start, end time.Time) (retrievedStats []model.EventValueStats, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, stats := range db.Store.eventValues {
+		if stats.Name == name && !stats.Time.Before(start) && stats.Time.Before(end) {
+			retrievedStats = append(retrievedStats, stats)
+		}
+	}
+
+	sort.Slice(retrievedStats, func(i, j int) bool {
+		return retrievedStats[i].Time.Before(retrievedStats[j].Time)
+	})
+
+	return retrievedStats, nil
+}
+
+func (db MemoryEventValueDB) GetEventValueBuckets(name string, start, end time.Time) (retrievedBuckets []model.EventValueBucket, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, bucket := range db.Store.valueBuckets {
+		if bucket.Name == name && !bucket.Time.Before(start) && bucket.Time.Before(end) {
+			retrievedBuckets = append(retrievedBuckets, bucket)
+		}
+	}
+
+	sort.Slice(retrievedBuckets, func(i, j int) bool {
+		if !retrievedBuckets[i].Time.Equal(retrievedBuckets[j].Time) {
+			return retrievedBuckets[i].Time.Before(retrievedBuckets[j].Time)
+		}
+		return retrievedBuckets[i].Bucket < retrievedBuckets[j].Bucket
+	})
+
+	return retrievedBuckets, nil
+}
+
+func (db MemoryEventValueDB) DeleteEventValues(name string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	for key, stats := range db.Store.eventValues {
+		if stats.Name == name {
+			delete(db.Store.eventValues, key)
+		}
+	}
+
+	for key, bucket := range db.Store.valueBuckets {
+		if bucket.Name == name {
+			delete(db.Store.valueBuckets, key)
+		}
+	}
+
+	return nil
+}
+
+type MemoryAPIKeyDB struct {
+	Store   *MemoryStore
+	Project string
+}
+
+func (db MemoryAPIKeyDB) ForProject(project string) APIKeyDBHandler {
+	return MemoryAPIKeyDB{Store: db.Store.project(DefaultProject), Project: project}
+}
+
+func (db MemoryAPIKeyDB) GetAPIKeys() (retrievedKeys []model.APIKey, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, key := range db.Store.apiKeys {
+		if key.Project == db.Project {
+			retrievedKeys = append(retrievedKeys, key)
+		}
+	}
+
+	sort.Slice(retrievedKeys, func(i, j int) bool { return retrievedKeys[i].ID < retrievedKeys[j].ID })
+
+	return retrievedKeys, nil
+}
+
+func (db MemoryAPIKeyDB) GetAPIKeyByHash(keyHash string) (retrievedKey model.APIKey, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	ID, ok := db.Store.apiKeyHashes[keyHash]
+	if !ok {
+		return model.APIKey{}, model.ErrAPIKeyNotFound
+	}
+
+	return db.Store.apiKeys[ID], nil
+}
+
+func (db MemoryAPIKeyDB) CreateAPIKey(key model.APIKey, keyHash string) (createdKey model.APIKey, err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	if _, ok := db.Store.apiKeyHashes[keyHash]; ok {
+		return model.APIKey{}, fmt.Errorf("duplicate api key hash")
+	}
+
+	db.Store.lastAPIKeyID++
+	key.ID = db.Store.lastAPIKeyID
+	key.Project = db.Project
+	key.RevokedAt = ""
+	db.Store.apiKeys[key.ID] = key
+	db.Store.apiKeyHashes[keyHash] = key.ID
+
+	return key, nil
+}
+
+func (db MemoryAPIKeyDB) UpdateAPIKeyHash(ID uint64, keyHash, prefix string) (updatedKey model.APIKey, err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	key, ok := db.Store.apiKeys[ID]
+	if !ok || key.Project != db.Project || key.RevokedAt != "" {
+		return model.APIKey{}, model.ErrAPIKeyNotFound
+	}
+
+	for hash, hashID := range db.Store.apiKeyHashes {
+		if hashID == ID {
+			delete(db.Store.apiKeyHashes, hash)
+		}
+	}
+	db.Store.apiKeyHashes[keyHash] = ID
+
+	key.Prefix = prefix
+	db.Store.apiKeys[ID] = key
+
+	return key, nil
+}
+
+func (db MemoryAPIKeyDB) RevokeAPIKey(ID uint64, revokedAt string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	key, ok := db.Store.apiKeys[ID]
+	if !ok || key.Project != db.Project {
+		return model.ErrAPIKeyNotFound
+	}
+
+	if key.RevokedAt == "" {
+		key.RevokedAt = revokedAt
+		db.Store.apiKeys[ID] = key
+	}
+
+	return nil
+}
+
+func (db MemoryAPIKeyDB) UpdateAPIKeyLimits(ID uint64, limits model.APIKeyLimits) (updatedKey model.APIKey, err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	key, ok := db.Store.apiKeys[ID]
+	if !ok || key.Project != db.Project {
+		return model.APIKey{}, model.ErrAPIKeyNotFound
+	}
+
+	key.APIKeyLimits = limits
+	db.Store.apiKeys[ID] = key
+
+	return key, nil
+}
+
+type MemoryAlertRuleDB struct {
+	Store   *MemoryStore
+	Project string
+}
+
+func (db MemoryAlertRuleDB) ForProject(project string) AlertRuleDBHandler {
+	return MemoryAlertRuleDB{Store: db.Store.project(DefaultProject), Project: project}
+}
+
+func (db MemoryAlertRuleDB) GetAlertProjects() (projects []string, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	seen := map[string]bool{}
+	for _, rule := range db.Store.alertRules {
+		if !seen[rule.Project] {
+			seen[rule.Project] = true
+			projects = append(projects, rule.Project)
+		}
+	}
+
+	sort.Strings(projects)
+
+	return projects, nil
+}
+
+func (db MemoryAlertRuleDB) GetAlertRules() (retrievedRules []model.AlertRule, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	for _, rule := range db.Store.alertRules {
+		if rule.Project == db.Project {
+			retrievedRules = append(retrievedRules, rule)
+		}
+	}
+
+	sort.Slice(retrievedRules, func(i, j int) bool { return retrievedRules[i].ID < retrievedRules[j].ID })
+
+	return retrievedRules, nil
+}
+
+func (db MemoryAlertRuleDB) GetAlertRule(ID uint64) (retrievedRule model.AlertRule, err error) {
+	db.Store.mu.RLock()
+	defer db.Store.mu.RUnlock()
+
+	rule, ok := db.Store.alertRules[ID]
+	if !ok || rule.Project != db.Project {
+		return model.AlertRule{}, model.ErrAlertRuleNotFound
+	}
+
+	return rule, nil
+}
+
+func (db MemoryAlertRuleDB) nameTaken(name string, ID uint64) bool {
+	for _, rule := range db.Store.alertRules {
+		if rule.Project == db.Project && rule.Name == name && rule.ID != ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (db MemoryAlertRuleDB) CreateAlertRule(rule model.AlertRule) (createdRule model.AlertRule, err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	if db.nameTaken(rule.Name, 0) {
+		return model.AlertRule{}, model.ErrAlertRuleExists
+	}
+
+	db.Store.lastAlertRuleID++
+	rule.ID = db.Store.lastAlertRuleID
+	rule.Project = db.Project
+	rule.Value = 0
+	rule.ChangedAt = ""
+	rule.EvaluatedAt = ""
+	db.Store.alertRules[rule.ID] = rule
+
+	return rule, nil
+}
+
+func (db MemoryAlertRuleDB) UpdateAlertRule(rule model.AlertRule) (updatedRule model.AlertRule, err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	updatedRule, ok := db.Store.alertRules[rule.ID]
+	if !ok || updatedRule.Project != db.Project {
+		return model.AlertRule{}, model.ErrAlertRuleNotFound
+	}
+	if db.nameTaken(rule.Name, rule.ID) {
+		return model.AlertRule{}, model.ErrAlertRuleExists
+	}
+
+	updatedRule.Name = rule.Name
+	updatedRule.Event = rule.Event
+	updatedRule.Condition = rule.Condition
+	updatedRule.Threshold = rule.Threshold
+	updatedRule.WindowMinutes = rule.WindowMinutes
+	updatedRule.WebhookURL = rule.WebhookURL
+	updatedRule.Secret = rule.Secret
+	db.Store.alertRules[rule.ID] = updatedRule
+
+	return updatedRule, nil
+}
+
+func (db MemoryAlertRuleDB) UpdateAlertRuleState(ID uint64, state string, value uint64, changedAt, evaluatedAt string) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	rule, ok := db.Store.alertRules[ID]
+	if !ok || rule.Project != db.Project {
+		return model.ErrAlertRuleNotFound
+	}
+
+	rule.State = state
+	rule.Value = value
+	rule.ChangedAt = changedAt
+	rule.EvaluatedAt = evaluatedAt
+	db.Store.alertRules[ID] = rule
+
+	return nil
+}
+
+func (db MemoryAlertRuleDB) DeleteAlertRule(ID uint64) (err error) {
+	db.Store.mu.Lock()
+	defer db.Store.mu.Unlock()
+
+	rule, ok := db.Store.alertRules[ID]
+	if !ok || rule.Project != db.Project {
+		return model.ErrAlertRuleNotFound
+	}
+
+	delete(db.Store.alertRules, ID)
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSeed(t *testing.T) {
+	store := NewMemoryStore()
+	store.Seed()
+
+	eventList, eventFreqList := DBsStartPoint(true)
+	for _, seedFreq := range eventFreqList {
+		name := seedFreq.Name
+
+		eventFreq, err := MemoryEventFreqDB{Store: store}.GetEventByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eventFreq.TotalCount != seedFreq.TotalCount || eventFreq.HourCount != seedFreq.HourCount {
+			t.Fatalf("%s frequency = %+v, want %d occurrences by hour %v", name, eventFreq, seedFreq.TotalCount, seedFreq.HourCount)
+		}
+
+		var weekdayTotal uint64
+		for _, count := range eventFreq.WeekdayCount {
+			weekdayTotal += count
+		}
+		if weekdayTotal != seedFreq.TotalCount {
+			t.Fatalf("%s has %d occurrences by weekday, want %d", name, weekdayTotal, seedFreq.TotalCount)
+		}
+
+		for _, seedEvent := range eventList {
+			if seedEvent.Name != name {
+				continue
+			}
+			date, _ := time.Parse("20060102", seedEvent.Date)
+
+			event, err := MemoryEventDB{Store: store}.GetEventByNameAndDate(name, date.Format("2006-01-02"))
+			if err != nil || event.Count != seedEvent.Count {
+				t.Fatalf("%s event of %s = %+v, %v, want %d occurrences", name, seedEvent.Date, event, err, seedEvent.Count)
+			}
+
+			var minuteTotal uint64
+			minutes, err := MemoryEventOccurrenceDB{Store: store}.GetEventOccurrences(name, date, date.AddDate(0, 0, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, minute := range minutes {
+				minuteTotal += minute.Count
+			}
+
+			var hourTotal uint64
+			hours, err := MemoryEventPropertyDB{Store: store}.GetEventProperties(name, event.Date, event.Date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, hour := range hours {
+				hourTotal += hour.Count
+			}
+
+			if minuteTotal != seedEvent.Count || hourTotal != seedEvent.Count {
+				t.Fatalf("%s of %s has %d occurrences by minute and %d by hour, want %d", name, seedEvent.Date, minuteTotal, hourTotal, seedEvent.Count)
+			}
+		}
+
+		users, err := MemoryEventOccurrenceDB{Store: store}.GetEventUsers([]string{name}, time.Time{}, time.Now())
+		if err != nil || len(users) == 0 {
+			t.Fatalf("%s users = %v, %v, want the seed users", name, users, err)
+		}
+	}
+}
+
+func TestMemoryEventIndex(t *testing.T) {
+	store := NewMemoryStore()
+	eventDB := MemoryEventDB{Store: store}
+
+	date := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		store.ingest("login", 2, date.Format("2006-01-02"), 10, uint64(date.Weekday()))
+	}
+
+	event, err := eventDB.GetEventByNameAndDate("login", "2021-01-01")
+	if err != nil || event.Count != 6 {
+		t.Fatalf("login event = %+v, %v, want 6 occurrences in a single row", event, err)
+	}
+	if len(store.events) != 1 {
+		t.Fatalf("eventDB has %d rows, want 1", len(store.events))
+	}
+
+	if err = eventDB.DeleteEvent(event.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = eventDB.GetEventByNameAndDate("login", "2021-01-01"); err == nil {
+		t.Fatal("the login event is still indexed after being deleted")
+	}
+
+	store.ingest("login", 1, "2021-01-01", 10, uint64(date.Weekday()))
+	event, err = eventDB.GetEventByNameAndDate("login", "2021-01-01")
+	if err != nil || event.Count != 1 {
+		t.Fatalf("login event = %+v, %v, want a new row with 1 occurrence", event, err)
+	}
+}
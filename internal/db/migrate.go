@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is read from migrations/<backend>/<version>_<name>.<up|down>.sql.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   uint64 `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"applied_at,omitempty"`
+}
+
+// migrationLockID is the Postgres advisory lock that serializes the instances migrating the same database.
+const migrationLockID = 4242001
+
+const migrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    BIGINT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at TEXT NOT NULL
+)`
+
+func LoadMigrations(backend string) (migrations []Migration, err error) {
+	entries, e := migrationFiles.ReadDir(path.Join("migrations", backend))
+	if e != nil {
+		return nil, errors.New(fmt.Sprintf(model.ErrUnknownStorage.Error(), backend))
+	}
+
+	byVersion := map[uint64]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		parts := strings.SplitN(strings.TrimSuffix(fileName, ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, errors.New(fmt.Sprintf(model.ErrMigrationFileName.Error(), fileName))
+		}
+
+		version, e := strconv.ParseUint(parts[0], 10, 64)
+		if e != nil {
+			return nil, errors.New(fmt.Sprintf(model.ErrMigrationFileName.Error(), fileName))
+		}
+
+		content, e := migrationFiles.ReadFile(path.Join("migrations", backend, fileName))
+		if e != nil {
+			return nil, e
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version}
+			byVersion[version] = migration
+		}
+
+		switch {
+		case strings.HasSuffix(parts[1], ".up"):
+			migration.Name = strings.TrimSuffix(parts[1], ".up")
+			migration.Up = string(content)
+		case strings.HasSuffix(parts[1], ".down"):
+			migration.Name = strings.TrimSuffix(parts[1], ".down")
+			migration.Down = string(content)
+		default:
+			return nil, errors.New(fmt.Sprintf(model.ErrMigrationFileName.Error(), fileName))
+		}
+	}
+
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func (s Storage) MigrateUp() (applied []Migration, err error) {
+	if s.Database == nil {
+		return nil, nil
+	}
+
+	err = s.withMigrationLock(func(conn *sql.Conn) error {
+		migrations, appliedVersions, e := s.migrationState(conn)
+		if e != nil {
+			return e
+		}
+
+		for _, migration := range migrations {
+			if _, ok := appliedVersions[migration.Version]; ok {
+				continue
+			}
+
+			e = s.runMigration(conn, migration.Up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339))
+			if e != nil {
+				return errors.New(fmt.Sprintf(model.ErrMigration.Error(), migration.Version, migration.Name, e.Error()))
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+func (s Storage) MigrateDown() (reverted Migration, ok bool, err error) {
+	if s.Database == nil {
+		return Migration{}, false, nil
+	}
+
+	err = s.withMigrationLock(func(conn *sql.Conn) error {
+		migrations, appliedVersions, e := s.migrationState(conn)
+		if e != nil {
+			return e
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			migration := migrations[i]
+			if _, applied := appliedVersions[migration.Version]; !applied {
+				continue
+			}
+
+			e = s.runMigration(conn, migration.Down, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+			if e != nil {
+				return errors.New(fmt.Sprintf(model.ErrMigration.Error(), migration.Version, migration.Name, e.Error()))
+			}
+
+			reverted, ok = migration, true
+			return nil
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	return reverted, ok, nil
+}
+
+func (s Storage) MigrationStatus() (status []MigrationStatus, err error) {
+	if s.Database == nil {
+		return nil, nil
+	}
+
+	err = s.withMigrationLock(func(conn *sql.Conn) error {
+		migrations, appliedVersions, e := s.migrationState(conn)
+		if e != nil {
+			return e
+		}
+
+		for _, migration := range migrations {
+			appliedAt, applied := appliedVersions[migration.Version]
+			status = append(status, MigrationStatus{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Applied:   applied,
+				AppliedAt: appliedAt,
+			})
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+func (s Storage) withMigrationLock(f func(conn *sql.Conn) error) (err error) {
+	ctx := context.Background()
+
+	conn, e := s.Database.Conn(ctx)
+	if e != nil {
+		return e
+	}
+	defer conn.Close()
+
+	if s.Backend == StoragePostgres {
+		_, e = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID)
+		if e != nil {
+			return e
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+	}
+
+	return f(conn)
+}
+
+func (s Storage) migrationState(conn *sql.Conn) (migrations []Migration, appliedVersions map[uint64]string, err error) {
+	migrations, e := LoadMigrations(s.Backend)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	_, e = conn.ExecContext(context.Background(), migrationsTable)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	rows, e := conn.QueryContext(context.Background(), "SELECT version, applied_at FROM schema_migrations")
+	if e != nil {
+		return nil, nil, e
+	}
+
+	appliedVersions = map[uint64]string{}
+	for rows.Next() {
+		var (
+			version   uint64
+			appliedAt string
+		)
+
+		e = rows.Scan(&version, &appliedAt)
+		if e != nil {
+			_ = rows.Close()
+			return nil, nil, e
+		}
+
+		appliedVersions[version] = appliedAt
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, nil, e
+	}
+
+	return migrations, appliedVersions, nil
+}
+
+func (s Storage) runMigration(conn *sql.Conn, script, bookkeeping string, args ...interface{}) (err error) {
+	tx, e := conn.BeginTx(context.Background(), nil)
+	if e != nil {
+		return e
+	}
+
+	_, e = tx.Exec(script)
+	if e != nil {
+		_ = tx.Rollback()
+		return e
+	}
+
+	_, e = tx.Exec(rebind(s.Backend, bookkeeping), args...)
+	if e != nil {
+		_ = tx.Rollback()
+		return e
+	}
+
+	return tx.Commit()
+}
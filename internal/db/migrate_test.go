@@ -0,0 +1,191 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMigrateUpDownStatus(t *testing.T) {
+	storage, err := OpenStorage(StorageSQLite, t.TempDir()+"/events.db", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = storage.Database.Close() })
+
+	migrations, err := LoadMigrations(StorageSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := migrations[len(migrations)-1]
+
+	appliedCount := func() (count int) {
+		status, err := storage.MigrationStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(status) != len(migrations) {
+			t.Fatalf("the status has %d migrations, want %d", len(status), len(migrations))
+		}
+		for _, migration := range status {
+			if migration.Applied {
+				count++
+			}
+		}
+		return count
+	}
+
+	if count := appliedCount(); count != 0 {
+		t.Fatalf("%d migrations are applied on a new database, want none", count)
+	}
+
+	applied, err := storage.MigrateUp()
+	if err != nil || len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, %v, want %d", len(applied), err, len(migrations))
+	}
+	if applied, err = storage.MigrateUp(); err != nil || len(applied) != 0 {
+		t.Fatalf("applied %d migrations again, %v, want none", len(applied), err)
+	}
+
+	reverted, ok, err := storage.MigrateDown()
+	if err != nil || !ok || reverted.Version != last.Version {
+		t.Fatalf("reverted %d, %v, %v, want %d", reverted.Version, ok, err, last.Version)
+	}
+	if count := appliedCount(); count != len(migrations)-1 {
+		t.Fatalf("%d migrations are applied after reverting one, want %d", count, len(migrations)-1)
+	}
+	if applied, err = storage.MigrateUp(); err != nil || len(applied) != 1 || applied[0].Version != last.Version {
+		t.Fatalf("applied %v, %v, want only %d", applied, err, last.Version)
+	}
+
+	for {
+		_, ok, err = storage.MigrateDown()
+		if err != nil {
+			t.Fatalf("migrating down: %v", err)
+		}
+		if !ok {
+			break
+		}
+	}
+	if count := appliedCount(); count != 0 {
+		t.Fatalf("%d migrations are applied after reverting all of them, want none", count)
+	}
+	var tables int
+	err = storage.Database.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')").Scan(&tables)
+	if err != nil || tables != 0 {
+		t.Fatalf("%d tables are left after reverting every migration, %v, want none", tables, err)
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	storage := openTestStorage(t, StorageSQLite)
+
+	for _, test := range []struct {
+		name, script, bookkeeping string
+	}{
+		{"script", "CREATE TABLE brokenDB (id INTEGER); INSERT INTO missingDB VALUES (1)", "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'broken', '')"},
+		{"bookkeeping", "CREATE TABLE brokenDB (id INTEGER)", "INSERT INTO missingDB VALUES (?)"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := storage.withMigrationLock(func(conn *sql.Conn) error {
+				return storage.runMigration(conn, test.script, test.bookkeeping, 999)
+			})
+			if err == nil {
+				t.Fatal("the broken migration succeeded")
+			}
+
+			var count int
+			if err = storage.Database.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = 999").Scan(&count); err != nil || count != 0 {
+				t.Fatalf("the broken migration was recorded %d times, %v", count, err)
+			}
+			if err = storage.Database.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'brokenDB'").Scan(&count); err != nil || count != 0 {
+				t.Fatalf("the table of the broken migration was kept, %v", err)
+			}
+		})
+	}
+}
+
+func TestConcurrentPostgresMigrations(t *testing.T) {
+	const instances = 4
+
+	dsn := postgresTestDSN(t)
+	migrations, err := LoadMigrations(StoragePostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied int
+	)
+	for i := 0; i < instances; i++ {
+		storage, err := OpenStorage(StoragePostgres, dsn, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = storage.Database.Close() })
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			migrated, err := storage.MigrateUp()
+			if err != nil {
+				t.Errorf("migrating up concurrently: %v", err)
+			}
+			mu.Lock()
+			applied += len(migrated)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if applied != len(migrations) {
+		t.Fatalf("the instances applied %d migrations, want %d", applied, len(migrations))
+	}
+}
+
+func TestBackfillEventOccurrences(t *testing.T) {
+	storage := openTestStorage(t, StorageSQLite)
+
+	reverted, ok, err := storage.MigrateDown()
+	if err != nil || !ok || reverted.Name != "backfill_event_occurrences" {
+		t.Fatalf("reverted %v, %v, %v, want the backfill", reverted, ok, err)
+	}
+
+	// The occurrences of 2021-01-01 were recorded before the counts by minute, 3 of them with their hour
+	// since the properties, and the one of 2021-01-02 after.
+	_, err = storage.Database.Exec(`INSERT INTO eventDB (project, date, name, count) VALUES ('default', '2021-01-01', 'login', 5);
+		INSERT INTO eventPropertyDB (project, name, date, hour, properties, count) VALUES ('default', 'login', '2021-01-01', 9, '{}', 3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.EventIngestHandler.IngestEvents([]model.EventOccurrence{{Name: "login", Count: 1, Date: time.Date(2021, 1, 2, 10, 5, 0, 0, time.UTC)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = storage.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := storage.EventOccurrenceDBHandler.GetEventOccurrences("login", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byMinute := map[string]uint64{}
+	for _, count := range counts {
+		byMinute[count.Time.UTC().Format(time.RFC3339)] += count.Count
+	}
+	want := map[string]uint64{"2021-01-01T09:00:00Z": 3, "2021-01-01T12:00:00Z": 2, "2021-01-02T10:05:00Z": 1}
+	if len(byMinute) != len(want) {
+		t.Fatalf("occurrences = %v, want %v", byMinute, want)
+	}
+	for minute, count := range want {
+		if byMinute[minute] != count {
+			t.Fatalf("occurrences = %v, want %v", byMinute, want)
+		}
+	}
+}
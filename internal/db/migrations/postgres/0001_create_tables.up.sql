@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS eventDB (
+	id    BIGSERIAL PRIMARY KEY,
+	date  TEXT NOT NULL,
+	name  TEXT NOT NULL,
+	count BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS eventFreqDB (
+	id         BIGSERIAL PRIMARY KEY,
+	name       TEXT NOT NULL,
+	count      BIGINT NOT NULL DEFAULT 0,
+	hour_count JSONB NOT NULL DEFAULT '[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]'
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS eventFreqDB_name_idx;
+DROP INDEX IF EXISTS eventDB_name_date_idx;
+DROP INDEX IF EXISTS eventDB_date_idx;
+DROP INDEX IF EXISTS eventDB_name_idx;
@@ -0,0 +1,10 @@
+-- Databases created before the migrations existed may hold several rows for the same
+-- event and day, which have to be merged before the unique index can be created.
+UPDATE eventDB SET count = (SELECT SUM(e.count) FROM eventDB e WHERE e.name = eventDB.name AND e.date = eventDB.date)
+WHERE id IN (SELECT MIN(id) FROM eventDB GROUP BY name, date);
+DELETE FROM eventDB WHERE id NOT IN (SELECT MIN(id) FROM eventDB GROUP BY name, date);
+
+CREATE INDEX IF NOT EXISTS eventDB_name_idx ON eventDB (name);
+CREATE INDEX IF NOT EXISTS eventDB_date_idx ON eventDB (date);
+CREATE UNIQUE INDEX IF NOT EXISTS eventDB_name_date_idx ON eventDB (name, date);
+CREATE UNIQUE INDEX IF NOT EXISTS eventFreqDB_name_idx ON eventFreqDB (name);
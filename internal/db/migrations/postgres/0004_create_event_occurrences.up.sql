@@ -0,0 +1,9 @@
+-- Occurrences counted by minute, keyed by the UTC start of the minute in RFC3339 format.
+CREATE TABLE IF NOT EXISTS eventOccurrenceDB (
+	id     BIGSERIAL PRIMARY KEY,
+	name   TEXT NOT NULL,
+	minute TEXT NOT NULL,
+	count  BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventOccurrenceDB_name_minute_idx ON eventOccurrenceDB (name, minute);
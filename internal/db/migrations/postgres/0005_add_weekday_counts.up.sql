@@ -0,0 +1,4 @@
+-- Weekday distribution (0 is Sunday) and weekday by hour matrix of the occurrences, in UTC.
+-- The rows created before this migration start with zero counts.
+ALTER TABLE eventFreqDB ADD COLUMN weekday_count JSONB NOT NULL DEFAULT '[0,0,0,0,0,0,0]';
+ALTER TABLE eventFreqDB ADD COLUMN weekday_hour_count JSONB NOT NULL DEFAULT '[[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]]';
@@ -0,0 +1,19 @@
+-- Occurrences aggregated by week (starting on Monday), month and year, keyed by the first day
+-- of the period in the YYYY-MM-DD format. The existing daily rows are rolled up once here, and
+-- the ingestion keeps the rollups up to date from then on.
+CREATE TABLE IF NOT EXISTS eventRollupDB (
+	id           BIGSERIAL PRIMARY KEY,
+	name         TEXT NOT NULL,
+	period       TEXT NOT NULL,
+	period_start TEXT NOT NULL,
+	count        BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventRollupDB_name_period_start_idx ON eventRollupDB (name, period, period_start);
+
+INSERT INTO eventRollupDB (name, period, period_start, count)
+SELECT name, 'week', to_char(date_trunc('week', date::date), 'YYYY-MM-DD'), SUM(count) FROM eventDB WHERE date ~ '^\d{4}-\d{2}-\d{2}$' GROUP BY 1, 2, 3;
+INSERT INTO eventRollupDB (name, period, period_start, count)
+SELECT name, 'month', to_char(date_trunc('month', date::date), 'YYYY-MM-DD'), SUM(count) FROM eventDB WHERE date ~ '^\d{4}-\d{2}-\d{2}$' GROUP BY 1, 2, 3;
+INSERT INTO eventRollupDB (name, period, period_start, count)
+SELECT name, 'year', to_char(date_trunc('year', date::date), 'YYYY-MM-DD'), SUM(count) FROM eventDB WHERE date ~ '^\d{4}-\d{2}-\d{2}$' GROUP BY 1, 2, 3;
@@ -0,0 +1,9 @@
+-- Idempotency keys of the recorded requests, with the hash of the request that used them. The
+-- creation time is in UTC, in a fixed width format so that the times sort as strings.
+CREATE TABLE IF NOT EXISTS idempotencyKeyDB (
+	idempotency_key TEXT PRIMARY KEY,
+	request_hash    TEXT NOT NULL,
+	created_at      TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotencyKeyDB_created_at_idx ON idempotencyKeyDB (created_at);
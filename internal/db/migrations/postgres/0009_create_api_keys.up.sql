@@ -0,0 +1,14 @@
+-- API keys, stored by the SHA-256 hash of the key. The scopes are comma separated, and the times
+-- are in RFC3339 UTC, empty when the key doesn't expire or hasn't been revoked.
+CREATE TABLE IF NOT EXISTS apiKeyDB (
+	id         BIGSERIAL PRIMARY KEY,
+	name       TEXT NOT NULL,
+	key_hash   TEXT NOT NULL,
+	prefix     TEXT NOT NULL,
+	scopes     TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL DEFAULT '',
+	revoked_at TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS apiKeyDB_key_hash_idx ON apiKeyDB (key_hash);
@@ -0,0 +1,3 @@
+ALTER TABLE apiKeyDB DROP COLUMN daily_quota;
+ALTER TABLE apiKeyDB DROP COLUMN burst;
+ALTER TABLE apiKeyDB DROP COLUMN rate_limit;
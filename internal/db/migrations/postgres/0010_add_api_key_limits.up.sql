@@ -0,0 +1,5 @@
+-- Rate limit (requests per second), burst and daily ingestion quota of the API keys. Zero means
+-- the key uses the defaults of the app.
+ALTER TABLE apiKeyDB ADD COLUMN rate_limit DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE apiKeyDB ADD COLUMN burst BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE apiKeyDB ADD COLUMN daily_quota BIGINT NOT NULL DEFAULT 0;
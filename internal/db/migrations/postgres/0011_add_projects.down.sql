@@ -0,0 +1,38 @@
+-- The rows of the other projects can't be told apart from the default project's once the column
+-- is gone, so they are deleted.
+DELETE FROM eventDB WHERE project <> 'default';
+DELETE FROM eventFreqDB WHERE project <> 'default';
+DELETE FROM eventPropertyDB WHERE project <> 'default';
+DELETE FROM eventOccurrenceDB WHERE project <> 'default';
+DELETE FROM eventRollupDB WHERE project <> 'default';
+DELETE FROM apiKeyDB WHERE project <> 'default';
+DELETE FROM eventRetentionDB WHERE project <> 'default';
+DELETE FROM idempotencyKeyDB WHERE project <> 'default';
+
+DROP INDEX IF EXISTS eventDB_project_name_idx;
+DROP INDEX IF EXISTS eventDB_project_name_date_idx;
+DROP INDEX IF EXISTS eventFreqDB_project_name_idx;
+DROP INDEX IF EXISTS eventPropertyDB_project_name_date_hour_properties_idx;
+DROP INDEX IF EXISTS eventOccurrenceDB_project_name_minute_idx;
+DROP INDEX IF EXISTS eventRollupDB_project_name_period_start_idx;
+DROP INDEX IF EXISTS apiKeyDB_project_idx;
+
+ALTER TABLE eventDB DROP COLUMN project;
+ALTER TABLE eventFreqDB DROP COLUMN project;
+ALTER TABLE eventPropertyDB DROP COLUMN project;
+ALTER TABLE eventOccurrenceDB DROP COLUMN project;
+ALTER TABLE eventRollupDB DROP COLUMN project;
+ALTER TABLE apiKeyDB DROP COLUMN project;
+
+CREATE INDEX IF NOT EXISTS eventDB_name_idx ON eventDB (name);
+CREATE UNIQUE INDEX IF NOT EXISTS eventDB_name_date_idx ON eventDB (name, date);
+CREATE UNIQUE INDEX IF NOT EXISTS eventFreqDB_name_idx ON eventFreqDB (name);
+CREATE UNIQUE INDEX IF NOT EXISTS eventPropertyDB_name_date_hour_properties_idx ON eventPropertyDB (name, date, hour, properties);
+CREATE UNIQUE INDEX IF NOT EXISTS eventOccurrenceDB_name_minute_idx ON eventOccurrenceDB (name, minute);
+CREATE UNIQUE INDEX IF NOT EXISTS eventRollupDB_name_period_start_idx ON eventRollupDB (name, period, period_start);
+
+ALTER TABLE eventRetentionDB DROP CONSTRAINT eventretentiondb_pkey, ADD PRIMARY KEY (name);
+ALTER TABLE eventRetentionDB DROP COLUMN project;
+
+ALTER TABLE idempotencyKeyDB DROP CONSTRAINT idempotencykeydb_pkey, ADD PRIMARY KEY (idempotency_key);
+ALTER TABLE idempotencyKeyDB DROP COLUMN project;
@@ -0,0 +1,29 @@
+-- Every row belongs to a project, which keeps apart the events of the teams sharing the tracker.
+-- The existing rows and API keys are moved to the default project.
+ALTER TABLE eventDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE eventFreqDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE eventPropertyDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE eventOccurrenceDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE eventRollupDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE apiKeyDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+
+DROP INDEX IF EXISTS eventDB_name_idx;
+DROP INDEX IF EXISTS eventDB_name_date_idx;
+DROP INDEX IF EXISTS eventFreqDB_name_idx;
+DROP INDEX IF EXISTS eventPropertyDB_name_date_hour_properties_idx;
+DROP INDEX IF EXISTS eventOccurrenceDB_name_minute_idx;
+DROP INDEX IF EXISTS eventRollupDB_name_period_start_idx;
+
+CREATE INDEX IF NOT EXISTS eventDB_project_name_idx ON eventDB (project, name);
+CREATE UNIQUE INDEX IF NOT EXISTS eventDB_project_name_date_idx ON eventDB (project, name, date);
+CREATE UNIQUE INDEX IF NOT EXISTS eventFreqDB_project_name_idx ON eventFreqDB (project, name);
+CREATE UNIQUE INDEX IF NOT EXISTS eventPropertyDB_project_name_date_hour_properties_idx ON eventPropertyDB (project, name, date, hour, properties);
+CREATE UNIQUE INDEX IF NOT EXISTS eventOccurrenceDB_project_name_minute_idx ON eventOccurrenceDB (project, name, minute);
+CREATE UNIQUE INDEX IF NOT EXISTS eventRollupDB_project_name_period_start_idx ON eventRollupDB (project, name, period, period_start);
+CREATE INDEX IF NOT EXISTS apiKeyDB_project_idx ON apiKeyDB (project);
+
+ALTER TABLE eventRetentionDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE eventRetentionDB DROP CONSTRAINT eventretentiondb_pkey, ADD PRIMARY KEY (project, name);
+
+ALTER TABLE idempotencyKeyDB ADD COLUMN project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE idempotencyKeyDB DROP CONSTRAINT idempotencykeydb_pkey, ADD PRIMARY KEY (project, idempotency_key);
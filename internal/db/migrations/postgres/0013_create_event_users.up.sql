@@ -0,0 +1,12 @@
+-- The minutes in which each user, as identified by the "user_id" of the ingestion, had occurrences
+-- of an event, keyed by the UTC start of the minute in RFC3339 format. The occurrences without a
+-- user aren't recorded here.
+CREATE TABLE IF NOT EXISTS eventUserDB (
+	id      BIGSERIAL PRIMARY KEY,
+	project TEXT NOT NULL DEFAULT 'default',
+	name    TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	minute  TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventUserDB_project_name_minute_user_idx ON eventUserDB (project, name, minute, user_id);
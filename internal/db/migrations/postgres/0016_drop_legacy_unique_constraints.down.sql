@@ -0,0 +1,2 @@
+-- The legacy constraints aren't restored: the projects may share event names by now, and the
+-- databases created by the migrations never had them.
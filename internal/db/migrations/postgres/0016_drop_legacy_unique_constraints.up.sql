@@ -0,0 +1,5 @@
+-- The databases created by the first Postgres backend, before the migrations existed, enforce the
+-- uniqueness of the events by name and date, and of the frequencies by name, with inline
+-- constraints instead of the indexes, which would keep two projects from sharing an event name.
+ALTER TABLE eventDB DROP CONSTRAINT IF EXISTS eventdb_name_date_key;
+ALTER TABLE eventFreqDB DROP CONSTRAINT IF EXISTS eventfreqdb_name_key;
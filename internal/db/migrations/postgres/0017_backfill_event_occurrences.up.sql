@@ -0,0 +1,21 @@
+-- The occurrences recorded before the counts by minute were kept only have their daily rows, and their
+-- hourly ones since the properties were introduced, so the reads by minute and in other time zones
+-- missed them. They are backfilled at the start of their UTC hour when it is known, and at noon UTC
+-- otherwise, which keeps them on their day in the time zones up to 12 hours away from UTC.
+INSERT INTO eventOccurrenceDB (project, name, minute, count)
+SELECT project, name, minute, missing FROM (
+	SELECT hours.project, hours.name, hours.date || 'T' || lpad(hours.hour::text, 2, '0') || ':00:00Z' AS minute,
+		hours.count - COALESCE((SELECT SUM(o.count) FROM eventOccurrenceDB o WHERE o.project = hours.project AND o.name = hours.name
+			AND o.minute BETWEEN hours.date || 'T' || lpad(hours.hour::text, 2, '0') || ':00:00Z' AND hours.date || 'T' || lpad(hours.hour::text, 2, '0') || ':59:00Z'), 0) AS missing
+	FROM (SELECT project, name, date, hour, SUM(count) AS count FROM eventPropertyDB GROUP BY project, name, date, hour) hours
+) backfill WHERE missing > 0
+ON CONFLICT (project, name, minute) DO UPDATE SET count = eventOccurrenceDB.count + excluded.count;
+
+INSERT INTO eventOccurrenceDB (project, name, minute, count)
+SELECT project, name, minute, missing FROM (
+	SELECT days.project, days.name, days.date || 'T12:00:00Z' AS minute,
+		days.count - COALESCE((SELECT SUM(o.count) FROM eventOccurrenceDB o WHERE o.project = days.project AND o.name = days.name
+			AND o.minute BETWEEN days.date || 'T00:00:00Z' AND days.date || 'T23:59:00Z'), 0) AS missing
+	FROM eventDB days
+) backfill WHERE missing > 0
+ON CONFLICT (project, name, minute) DO UPDATE SET count = eventOccurrenceDB.count + excluded.count;
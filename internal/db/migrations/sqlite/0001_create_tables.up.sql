@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS eventDB (
+	id    INTEGER PRIMARY KEY AUTOINCREMENT,
+	date  TEXT NOT NULL,
+	name  TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS eventFreqDB (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT NOT NULL,
+	count      INTEGER NOT NULL DEFAULT 0,
+	hour_count TEXT NOT NULL DEFAULT '[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]'
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS eventPropertyDB (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT NOT NULL,
+	date       TEXT NOT NULL,
+	hour       INTEGER NOT NULL,
+	properties TEXT NOT NULL DEFAULT '{}',
+	count      INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventPropertyDB_name_date_hour_properties_idx ON eventPropertyDB (name, date, hour, properties);
+CREATE INDEX IF NOT EXISTS eventPropertyDB_date_idx ON eventPropertyDB (date);
@@ -0,0 +1,2 @@
+ALTER TABLE eventFreqDB DROP COLUMN weekday_hour_count;
+ALTER TABLE eventFreqDB DROP COLUMN weekday_count;
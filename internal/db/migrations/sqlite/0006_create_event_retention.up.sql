@@ -0,0 +1,6 @@
+-- Retention of the daily rows, in days. The row with an empty name is the global retention,
+-- which applies to the events without their own.
+CREATE TABLE IF NOT EXISTS eventRetentionDB (
+	name TEXT PRIMARY KEY,
+	days INTEGER NOT NULL
+);
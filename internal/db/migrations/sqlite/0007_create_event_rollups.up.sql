@@ -0,0 +1,19 @@
+-- Occurrences aggregated by week (starting on Monday), month and year, keyed by the first day
+-- of the period in the YYYY-MM-DD format. The existing daily rows are rolled up once here, and
+-- the ingestion keeps the rollups up to date from then on.
+CREATE TABLE IF NOT EXISTS eventRollupDB (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	name         TEXT NOT NULL,
+	period       TEXT NOT NULL,
+	period_start TEXT NOT NULL,
+	count        INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventRollupDB_name_period_start_idx ON eventRollupDB (name, period, period_start);
+
+INSERT INTO eventRollupDB (name, period, period_start, count)
+SELECT name, 'week', date(date, '-6 days', 'weekday 1'), SUM(count) FROM eventDB WHERE date LIKE '____-__-__' GROUP BY 1, 2, 3;
+INSERT INTO eventRollupDB (name, period, period_start, count)
+SELECT name, 'month', strftime('%Y-%m-01', date), SUM(count) FROM eventDB WHERE date LIKE '____-__-__' GROUP BY 1, 2, 3;
+INSERT INTO eventRollupDB (name, period, period_start, count)
+SELECT name, 'year', strftime('%Y-01-01', date), SUM(count) FROM eventDB WHERE date LIKE '____-__-__' GROUP BY 1, 2, 3;
@@ -0,0 +1,49 @@
+-- The rows of the other projects can't be told apart from the default project's once the column
+-- is gone, so they are deleted.
+DELETE FROM eventDB WHERE project <> 'default';
+DELETE FROM eventFreqDB WHERE project <> 'default';
+DELETE FROM eventPropertyDB WHERE project <> 'default';
+DELETE FROM eventOccurrenceDB WHERE project <> 'default';
+DELETE FROM eventRollupDB WHERE project <> 'default';
+DELETE FROM apiKeyDB WHERE project <> 'default';
+
+DROP INDEX IF EXISTS eventDB_project_name_idx;
+DROP INDEX IF EXISTS eventDB_project_name_date_idx;
+DROP INDEX IF EXISTS eventFreqDB_project_name_idx;
+DROP INDEX IF EXISTS eventPropertyDB_project_name_date_hour_properties_idx;
+DROP INDEX IF EXISTS eventOccurrenceDB_project_name_minute_idx;
+DROP INDEX IF EXISTS eventRollupDB_project_name_period_start_idx;
+DROP INDEX IF EXISTS apiKeyDB_project_idx;
+
+ALTER TABLE eventDB DROP COLUMN project;
+ALTER TABLE eventFreqDB DROP COLUMN project;
+ALTER TABLE eventPropertyDB DROP COLUMN project;
+ALTER TABLE eventOccurrenceDB DROP COLUMN project;
+ALTER TABLE eventRollupDB DROP COLUMN project;
+ALTER TABLE apiKeyDB DROP COLUMN project;
+
+CREATE INDEX IF NOT EXISTS eventDB_name_idx ON eventDB (name);
+CREATE UNIQUE INDEX IF NOT EXISTS eventDB_name_date_idx ON eventDB (name, date);
+CREATE UNIQUE INDEX IF NOT EXISTS eventFreqDB_name_idx ON eventFreqDB (name);
+CREATE UNIQUE INDEX IF NOT EXISTS eventPropertyDB_name_date_hour_properties_idx ON eventPropertyDB (name, date, hour, properties);
+CREATE UNIQUE INDEX IF NOT EXISTS eventOccurrenceDB_name_minute_idx ON eventOccurrenceDB (name, minute);
+CREATE UNIQUE INDEX IF NOT EXISTS eventRollupDB_name_period_start_idx ON eventRollupDB (name, period, period_start);
+
+CREATE TABLE eventRetentionDB_old (
+	name TEXT PRIMARY KEY,
+	days INTEGER NOT NULL
+);
+INSERT INTO eventRetentionDB_old (name, days) SELECT name, days FROM eventRetentionDB WHERE project = 'default';
+DROP TABLE eventRetentionDB;
+ALTER TABLE eventRetentionDB_old RENAME TO eventRetentionDB;
+
+CREATE TABLE idempotencyKeyDB_old (
+	idempotency_key TEXT PRIMARY KEY,
+	request_hash    TEXT NOT NULL,
+	created_at      TEXT NOT NULL
+);
+INSERT INTO idempotencyKeyDB_old (idempotency_key, request_hash, created_at) SELECT idempotency_key, request_hash, created_at FROM idempotencyKeyDB WHERE project = 'default';
+DROP TABLE idempotencyKeyDB;
+ALTER TABLE idempotencyKeyDB_old RENAME TO idempotencyKeyDB;
+
+CREATE INDEX IF NOT EXISTS idempotencyKeyDB_created_at_idx ON idempotencyKeyDB (created_at);
@@ -0,0 +1,21 @@
+-- Alert rules, which fire when the occurrences of an event within the last window_minutes are above,
+-- or below, the threshold. The state and the value are the ones of the last evaluation, and the
+-- times are in RFC3339 UTC, empty until the rule is evaluated or changes state.
+CREATE TABLE IF NOT EXISTS alertRuleDB (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	project        TEXT NOT NULL DEFAULT 'default',
+	name           TEXT NOT NULL,
+	event          TEXT NOT NULL,
+	condition      TEXT NOT NULL,
+	threshold      INTEGER NOT NULL,
+	window_minutes INTEGER NOT NULL,
+	webhook_url    TEXT NOT NULL,
+	secret         TEXT NOT NULL DEFAULT '',
+	state          TEXT NOT NULL DEFAULT 'ok',
+	value          INTEGER NOT NULL DEFAULT 0,
+	changed_at     TEXT NOT NULL DEFAULT '',
+	evaluated_at   TEXT NOT NULL DEFAULT '',
+	created_at     TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS alertRuleDB_project_name_idx ON alertRuleDB (project, name);
@@ -0,0 +1,13 @@
+-- The HyperLogLog sketches of the users of each event by day (UTC), in the YYYY-MM-DD format, kept
+-- as one row per register that isn't empty, with the rank of the register. The sketches of several
+-- days are merged by taking the highest rank of each register.
+CREATE TABLE IF NOT EXISTS eventUniqueDB (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	project  TEXT NOT NULL DEFAULT 'default',
+	name     TEXT NOT NULL,
+	date     TEXT NOT NULL,
+	register INTEGER NOT NULL,
+	rank     INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventUniqueDB_project_name_date_register_idx ON eventUniqueDB (project, name, date, register);
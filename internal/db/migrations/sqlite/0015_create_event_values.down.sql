@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS eventValueBucketDB;
+DROP TABLE IF EXISTS eventValueDB;
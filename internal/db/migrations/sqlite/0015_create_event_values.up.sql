@@ -0,0 +1,29 @@
+-- The numeric values of the occurrences of each event by hour, keyed by the UTC start of the hour in
+-- RFC3339 format: their number, total, minimum and maximum, from which the means are computed.
+-- The occurrences without a value aren't recorded here.
+CREATE TABLE IF NOT EXISTS eventValueDB (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	project TEXT NOT NULL DEFAULT 'default',
+	name    TEXT NOT NULL,
+	hour    TEXT NOT NULL,
+	count   INTEGER NOT NULL DEFAULT 0,
+	total   REAL NOT NULL DEFAULT 0,
+	minimum REAL NOT NULL,
+	maximum REAL NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventValueDB_project_name_hour_idx ON eventValueDB (project, name, hour);
+
+-- The DDSketch of the values of each event by hour, kept as one row per bucket that isn't empty, with
+-- the number of values of the bucket. The sketches of several hours are merged by adding up the counts
+-- of each bucket.
+CREATE TABLE IF NOT EXISTS eventValueBucketDB (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	project TEXT NOT NULL DEFAULT 'default',
+	name    TEXT NOT NULL,
+	hour    TEXT NOT NULL,
+	bucket  INTEGER NOT NULL,
+	count   INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS eventValueBucketDB_project_name_hour_bucket_idx ON eventValueBucketDB (project, name, hour, bucket);
@@ -0,0 +1 @@
+-- The backfilled counts can't be told apart from the recorded ones, so they are kept.
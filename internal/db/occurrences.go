@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+	"strings"
+	"time"
+)
+
+// EventOccurrenceDBHandler reads the counts by minute of eventOccurrenceDB, and the minutes of the users of eventUserDB.
+type EventOccurrenceDBHandler interface {
+	// GetEventOccurrences reads every event when name is empty, from start (included) to end (excluded).
+	GetEventOccurrences(name string, start, end time.Time) (retrievedCounts []model.EventTimeCount, err error)
+	// GetEventUsers returns the minutes sorted by user and then by minute.
+	GetEventUsers(names []string, start, end time.Time) (retrievedUsers []model.EventUserTime, err error)
+	DeleteEventOccurrences(name string) (err error)
+	ForProject(project string) EventOccurrenceDBHandler
+}
+
+type EventOccurrenceDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db EventOccurrenceDB) ForProject(project string) EventOccurrenceDBHandler {
+	db.Project = project
+	return db
+}
+
+const upsertEventOccurrenceQuery = `INSERT INTO eventOccurrenceDB (project, name, minute, count) VALUES (?, ?, ?, ?)
+	ON CONFLICT (project, name, minute) DO UPDATE SET count = eventOccurrenceDB.count + excluded.count`
+
+const insertEventUserQuery = `INSERT INTO eventUserDB (project, name, user_id, minute) VALUES (?, ?, ?, ?)
+	ON CONFLICT (project, name, minute, user_id) DO NOTHING`
+
+// minuteKey is in UTC and of a fixed width, so that the keys sort like the dates.
+func minuteKey(date time.Time) string {
+	return date.UTC().Truncate(time.Minute).Format(time.RFC3339)
+}
+
+func (db EventOccurrenceDB) GetEventOccurrences(name string, start, end time.Time) (retrievedCounts []model.EventTimeCount, err error) {
+	query := "SELECT name, minute, count FROM eventOccurrenceDB WHERE project = ?"
+	args := []interface{}{db.Project}
+	if name != "" {
+		query += " AND name = ?"
+		args = append(args, name)
+	}
+	if !start.IsZero() {
+		query += " AND minute >= ?"
+		args = append(args, minuteKey(start))
+	}
+	if !end.IsZero() {
+		query += " AND minute < ?"
+		args = append(args, minuteKey(end))
+	}
+	query += " ORDER BY name, minute"
+
+	rows, e := db.Database.Query(rebind(db.Backend, query), args...)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var (
+			count  model.EventTimeCount
+			minute string
+		)
+
+		e = rows.Scan(&count.Name, &minute, &count.Count)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		count.Time, e = time.Parse(time.RFC3339, minute)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedCounts = append(retrievedCounts, count)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedCounts, nil
+}
+
+func (db EventOccurrenceDB) GetEventUsers(names []string, start, end time.Time) (retrievedUsers []model.EventUserTime, err error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT name, user_id, minute FROM eventUserDB WHERE project = ? AND name IN (?" + strings.Repeat(", ?", len(names)-1) + ") AND minute >= ? AND minute < ? ORDER BY user_id, minute"
+	args := []interface{}{db.Project}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	args = append(args, minuteKey(start), minuteKey(end))
+
+	rows, e := db.Database.Query(rebind(db.Backend, query), args...)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var (
+			user   model.EventUserTime
+			minute string
+		)
+
+		e = rows.Scan(&user.Name, &user.UserID, &minute)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		user.Time, e = time.Parse(time.RFC3339, minute)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedUsers = append(retrievedUsers, user)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedUsers, nil
+}
+
+func (db EventOccurrenceDB) DeleteEventOccurrences(name string) (err error) {
+	for _, table := range []string{"eventOccurrenceDB", "eventUserDB"} {
+		_, e := db.Database.Exec(rebind(db.Backend, "DELETE FROM "+table+" WHERE project = ? AND name = ?"), db.Project, name)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// legacyPostgresSchema is the schema created by the first Postgres backend, before the migrations
+// existed, with the uniqueness of the events enforced by inline constraints.
+const legacyPostgresSchema = `
+CREATE TABLE IF NOT EXISTS eventDB (
+	id    BIGSERIAL PRIMARY KEY,
+	date  TEXT NOT NULL,
+	name  TEXT NOT NULL,
+	count BIGINT NOT NULL DEFAULT 0,
+	UNIQUE (name, date)
+);
+CREATE TABLE IF NOT EXISTS eventFreqDB (
+	id         BIGSERIAL PRIMARY KEY,
+	name       TEXT NOT NULL UNIQUE,
+	count      BIGINT NOT NULL DEFAULT 0,
+	hour_count JSONB NOT NULL DEFAULT '[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]'
+);`
+
+// postgresTestDSN returns the connection string of the Postgres server of the POSTGRES_TEST_DSN environment
+// variable on a schema of its own, which is dropped at the end of the test. The test is skipped when it isn't set.
+func postgresTestDSN(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("opening %s: %v", dsn, err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	schema := fmt.Sprintf("event_tracker_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	if err != nil {
+		t.Fatalf("creating schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() { _, _ = admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	separator := " "
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		separator = "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+	}
+
+	return dsn + separator + "search_path=" + schema
+}
+
+// openPostgresTestStorage opens a postgres storage on the schema of postgresTestDSN.
+func openPostgresTestStorage(t *testing.T) Storage {
+	t.Helper()
+
+	storage, err := OpenStorage(StoragePostgres, postgresTestDSN(t), false)
+	if err != nil {
+		t.Fatalf("opening the postgres storage: %v", err)
+	}
+	t.Cleanup(func() { _ = storage.Database.Close() })
+
+	return storage
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT id FROM eventDB WHERE project = ? AND name = ? AND date BETWEEN ? AND ?"
+
+	got := rebind(StoragePostgres, query)
+	want := "SELECT id FROM eventDB WHERE project = $1 AND name = $2 AND date BETWEEN $3 AND $4"
+	if got != want {
+		t.Fatalf("rebind(postgres) = %q, want %q", got, want)
+	}
+
+	for _, backend := range []string{StorageSQLite, StorageMemory} {
+		if got := rebind(backend, query); got != query {
+			t.Fatalf("rebind(%s) = %q, want the query unchanged", backend, got)
+		}
+	}
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	storage := openPostgresTestStorage(t)
+
+	applied, err := storage.MigrateUp()
+	if err != nil {
+		t.Fatalf("migrating up: %v", err)
+	}
+	migrations, err := LoadMigrations(StoragePostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	for _, table := range []string{"eventdb", "eventfreqdb", "eventpropertydb", "eventoccurrencedb", "eventrollupdb", "apikeydb", "alertruledb", "eventuniquedb", "eventvaluedb"} {
+		var exists bool
+		err = storage.Database.QueryRow("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1)", table).Scan(&exists)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exists {
+			t.Fatalf("table %s wasn't created", table)
+		}
+	}
+
+	for {
+		_, ok, err := storage.MigrateDown()
+		if err != nil {
+			t.Fatalf("migrating down: %v", err)
+		}
+		if !ok {
+			break
+		}
+	}
+	if _, err = storage.MigrateUp(); err != nil {
+		t.Fatalf("migrating up again: %v", err)
+	}
+}
+
+func TestPostgresLegacySchema(t *testing.T) {
+	storage := openPostgresTestStorage(t)
+
+	_, err := storage.Database.Exec(legacyPostgresSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = storage.MigrateUp(); err != nil {
+		t.Fatalf("migrating the legacy schema up: %v", err)
+	}
+
+	// The same event and day in two projects would break the inline constraints.
+	occurrence := model.EventOccurrence{Name: "login", Count: 1, Date: time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)}
+	for _, project := range []string{DefaultProject, "team-a"} {
+		err = storage.EventIngestHandler.ForProject(project).IngestEvents([]model.EventOccurrence{occurrence})
+		if err != nil {
+			t.Fatalf("ingesting in project %s: %v", project, err)
+		}
+	}
+
+	for _, project := range []string{DefaultProject, "team-a"} {
+		events, err := storage.EventDBHandler.ForProject(project).GetEventsByName("login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Count != 1 {
+			t.Fatalf("project %s has events %v, want a single occurrence", project, events)
+		}
+	}
+}
+
+func TestEventUpserts(t *testing.T) {
+	for _, backend := range []string{StorageSQLite, StoragePostgres} {
+		t.Run(backend, func(t *testing.T) {
+			storage := openTestStorage(t, backend)
+
+			occurrences := []model.EventOccurrence{
+				{Name: "login", Count: 2, Date: time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)},
+				{Name: "login", Count: 3, Date: time.Date(2021, 1, 1, 15, 0, 0, 0, time.UTC)},
+				{Name: "login", Count: 4, Date: time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC)},
+				{Name: "logout", Count: 1, Date: time.Date(2021, 1, 2, 23, 0, 0, 0, time.UTC)},
+			}
+			for i := 0; i < 2; i++ {
+				if err := storage.EventIngestHandler.IngestEvents(occurrences); err != nil {
+					t.Fatalf("ingesting: %v", err)
+				}
+			}
+
+			events, err := storage.EventDBHandler.GetEventsByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := map[string]uint64{}
+			for _, event := range events {
+				counts[event.Date] += event.Count
+			}
+			if len(events) != 2 || counts["2021-01-01"] != 10 || counts["2021-01-02"] != 8 {
+				t.Fatalf("login events = %v, want one row per day with 10 and 8 occurrences", events)
+			}
+
+			event, err := storage.EventDBHandler.GetEventByNameAndDate("logout", "2021-01-02")
+			if err != nil || event.Count != 2 {
+				t.Fatalf("logout event = %v, %v, want 2 occurrences", event, err)
+			}
+
+			events, err = storage.EventDBHandler.GetEventsByDateRange("2021-01-02", "2021-01-02")
+			if err != nil || len(events) != 2 {
+				t.Fatalf("events of 2021-01-02 = %v, %v, want login and logout", events, err)
+			}
+
+			for i := 0; i < 2; i++ {
+				if err = storage.EventDBHandler.CreateEvent("signup", 1, "2021-01-03"); err != nil {
+					t.Fatalf("creating the signup event: %v", err)
+				}
+			}
+			event, err = storage.EventDBHandler.GetEventByNameAndDate("signup", "2021-01-03")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = storage.EventDBHandler.UpdateEvent(event.ID, 3); err != nil {
+				t.Fatal(err)
+			}
+			event, err = storage.EventDBHandler.GetEventByID(event.ID)
+			if err != nil || event.Count != 5 {
+				t.Fatalf("signup event = %v, %v, want 5 occurrences", event, err)
+			}
+
+			eventFreq, err := storage.EventFreqDBHandler.GetEventByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eventFreq.TotalCount != 18 || eventFreq.HourCount[10] != 12 || eventFreq.HourCount[15] != 6 {
+				t.Fatalf("login frequency = %+v, want 18 occurrences, 12 at 10h and 6 at 15h", eventFreq)
+			}
+			if eventFreq.WeekdayCount[time.Friday] != 10 || eventFreq.WeekdayCount[time.Saturday] != 8 {
+				t.Fatalf("login weekdays = %v, want 10 on Friday and 8 on Saturday", eventFreq.WeekdayCount)
+			}
+
+			IDs, err := storage.EventDBHandler.GetEventsIDsByName("login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = storage.EventDBHandler.DeleteEvents(IDs); err != nil {
+				t.Fatal(err)
+			}
+			if err = storage.EventFreqDBHandler.DeleteEvent(eventFreq.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = storage.EventFreqDBHandler.GetEventByName("login"); err == nil {
+				t.Fatal("the login frequency is still there after being deleted")
+			}
+		})
+	}
+}
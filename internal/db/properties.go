@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"eventTracker/internal/model"
+)
+
+// EventPropertyDBHandler reads the counts of eventPropertyDB by day, hour and set of properties.
+type EventPropertyDBHandler interface {
+	// GetEventProperties reads every event when name is empty, and empty dates leave the range open.
+	GetEventProperties(name, startDate, endDate string) (retrievedCounts []model.EventPropertyCount, err error)
+	DeleteEventProperties(name string) (err error)
+	ForProject(project string) EventPropertyDBHandler
+}
+
+type EventPropertyDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db EventPropertyDB) ForProject(project string) EventPropertyDBHandler {
+	db.Project = project
+	return db
+}
+
+const upsertEventPropertyQuery = `INSERT INTO eventPropertyDB (project, name, date, hour, properties, count) VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT (project, name, date, hour, properties) DO UPDATE SET count = eventPropertyDB.count + excluded.count`
+
+// encodeProperties identifies a set of properties whatever the order they were sent in.
+func encodeProperties(properties map[string]string) (string, error) {
+	if len(properties) == 0 {
+		return "{}", nil
+	}
+
+	propertiesBytes, e := json.Marshal(properties)
+	if e != nil {
+		return "", e
+	}
+
+	return string(propertiesBytes), nil
+}
+
+func (db EventPropertyDB) GetEventProperties(name, startDate, endDate string) (retrievedCounts []model.EventPropertyCount, err error) {
+	query := "SELECT name, date, hour, properties, count FROM eventPropertyDB WHERE project = ?"
+	args := []interface{}{db.Project}
+	if name != "" {
+		query += " AND name = ?"
+		args = append(args, name)
+	}
+	if startDate != "" {
+		query += " AND date >= ?"
+		args = append(args, startDate)
+	}
+	if endDate != "" {
+		query += " AND date <= ?"
+		args = append(args, endDate)
+	}
+	query += " ORDER BY name, date, hour"
+
+	rows, e := db.Database.Query(rebind(db.Backend, query), args...)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var (
+			count            model.EventPropertyCount
+			propertiesString string
+		)
+
+		e = rows.Scan(&count.Name, &count.Date, &count.Hour, &propertiesString, &count.Count)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		e = json.Unmarshal([]byte(propertiesString), &count.Properties)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedCounts = append(retrievedCounts, count)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedCounts, nil
+}
+
+func (db EventPropertyDB) DeleteEventProperties(name string) (err error) {
+	_, e := db.Database.Exec(rebind(db.Backend, "DELETE FROM eventPropertyDB WHERE project = ? AND name = ?"), db.Project, name)
+	if e != nil {
+		return e
+	}
+
+	return nil
+}
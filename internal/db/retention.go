@@ -0,0 +1,321 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"eventTracker/internal/model"
+	"time"
+)
+
+type EventRetentionDBHandler interface {
+	// GetRetentions returns the global retention with an empty name.
+	GetRetentions() (retentions []model.EventRetention, err error)
+	SetRetention(name string, days uint64) (err error)
+	DeleteRetention(name string) (err error)
+	// PruneEvents deletes up to limit daily rows before beforeDate, with every count of those days.
+	PruneEvents(name, beforeDate string, limit int) (pruned int, err error)
+	// GetProjects returns the projects with an event or a retention, whichever the project of the handler.
+	GetProjects() (projects []string, err error)
+	ForProject(project string) EventRetentionDBHandler
+}
+
+type EventRetentionDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db EventRetentionDB) ForProject(project string) EventRetentionDBHandler {
+	db.Project = project
+	return db
+}
+
+func (db EventRetentionDB) GetRetentions() (retentions []model.EventRetention, err error) {
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT name, days FROM eventRetentionDB WHERE project = ? ORDER BY name"), db.Project)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var retention model.EventRetention
+
+		e = rows.Scan(&retention.Name, &retention.Days)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retentions = append(retentions, retention)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retentions, nil
+}
+
+func (db EventRetentionDB) SetRetention(name string, days uint64) (err error) {
+	_, e := db.Database.Exec(rebind(db.Backend, `INSERT INTO eventRetentionDB (project, name, days) VALUES (?, ?, ?)
+		ON CONFLICT (project, name) DO UPDATE SET days = excluded.days`), db.Project, name, days)
+	if e != nil {
+		return e
+	}
+
+	return nil
+}
+
+func (db EventRetentionDB) DeleteRetention(name string) (err error) {
+	result, e := db.Database.Exec(rebind(db.Backend, "DELETE FROM eventRetentionDB WHERE project = ? AND name = ?"), db.Project, name)
+	if e != nil {
+		return e
+	}
+
+	deleted, e := result.RowsAffected()
+	if e != nil {
+		return e
+	}
+	if deleted == 0 {
+		return model.ErrRetentionNotFound
+	}
+
+	return nil
+}
+
+func (db EventRetentionDB) GetProjects() (projects []string, err error) {
+	rows, e := db.Database.Query("SELECT project FROM eventFreqDB UNION SELECT project FROM eventRetentionDB ORDER BY project")
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var project string
+
+		e = rows.Scan(&project)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		projects = append(projects, project)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return projects, nil
+}
+
+func (db EventRetentionDB) PruneEvents(name, beforeDate string, limit int) (pruned int, err error) {
+	tx, e := db.Database.Begin()
+	if e != nil {
+		return 0, e
+	}
+
+	rows, e := tx.Query(rebind(db.Backend, "SELECT date, count FROM eventDB WHERE project = ? AND name = ? AND date < ? ORDER BY date LIMIT ?"), db.Project, name, beforeDate, limit)
+	if e != nil {
+		_ = tx.Rollback()
+		return 0, e
+	}
+
+	var (
+		lastDate    string
+		totalCount  uint64
+		dailyCounts []model.Event
+	)
+	for rows.Next() {
+		var count uint64
+
+		e = rows.Scan(&lastDate, &count)
+		if e != nil {
+			_ = rows.Close()
+			_ = tx.Rollback()
+			return 0, e
+		}
+
+		totalCount += count
+		dailyCounts = append(dailyCounts, model.Event{Name: name, Date: lastDate, Count: count})
+		pruned++
+	}
+
+	e = rows.Close()
+	if e != nil {
+		_ = tx.Rollback()
+		return 0, e
+	}
+
+	if pruned == 0 {
+		return 0, tx.Rollback()
+	}
+
+	// The daily rows are unique by date, so the batch holds every row up to its last date.
+	rows, e = tx.Query(rebind(db.Backend, "SELECT date, hour, SUM(count) FROM eventPropertyDB WHERE project = ? AND name = ? AND date <= ? GROUP BY date, hour"), db.Project, name, lastDate)
+	if e != nil {
+		_ = tx.Rollback()
+		return 0, e
+	}
+
+	var hourlyCounts []model.EventPropertyCount
+	for rows.Next() {
+		var count model.EventPropertyCount
+
+		e = rows.Scan(&count.Date, &count.Hour, &count.Count)
+		if e != nil {
+			_ = rows.Close()
+			_ = tx.Rollback()
+			return 0, e
+		}
+
+		hourlyCounts = append(hourlyCounts, count)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		_ = tx.Rollback()
+		return 0, e
+	}
+
+	e = db.subtractEventFreq(tx, name, totalCount, hourlyCounts)
+	if e != nil {
+		_ = tx.Rollback()
+		return 0, e
+	}
+
+	e = db.subtractEventRollups(tx, name, dailyCounts)
+	if e != nil {
+		_ = tx.Rollback()
+		return 0, e
+	}
+
+	nextDay, e := time.Parse("2006-01-02", lastDate)
+	if e != nil {
+		_ = tx.Rollback()
+		return 0, e
+	}
+
+	deletes := []struct {
+		query string
+		bound string
+	}{
+		{"DELETE FROM eventDB WHERE project = ? AND name = ? AND date <= ?", lastDate},
+		{"DELETE FROM eventPropertyDB WHERE project = ? AND name = ? AND date <= ?", lastDate},
+		{"DELETE FROM eventOccurrenceDB WHERE project = ? AND name = ? AND minute < ?", minuteKey(nextDay.AddDate(0, 0, 1))},
+		{"DELETE FROM eventUserDB WHERE project = ? AND name = ? AND minute < ?", minuteKey(nextDay.AddDate(0, 0, 1))},
+		{"DELETE FROM eventUniqueDB WHERE project = ? AND name = ? AND date <= ?", lastDate},
+		{"DELETE FROM eventValueDB WHERE project = ? AND name = ? AND hour < ?", hourKey(nextDay.AddDate(0, 0, 1))},
+		{"DELETE FROM eventValueBucketDB WHERE project = ? AND name = ? AND hour < ?", hourKey(nextDay.AddDate(0, 0, 1))},
+	}
+	for _, d := range deletes {
+		_, e = tx.Exec(rebind(db.Backend, d.query), db.Project, name, d.bound)
+		if e != nil {
+			_ = tx.Rollback()
+			return 0, e
+		}
+	}
+
+	e = tx.Commit()
+	if e != nil {
+		return 0, e
+	}
+
+	return pruned, nil
+}
+
+// subtractEventFreq locks the row until the end of the transaction, so that no concurrent ingestion is overwritten.
+func (db EventRetentionDB) subtractEventFreq(tx *sql.Tx, name string, totalCount uint64, hourlyCounts []model.EventPropertyCount) (err error) {
+	query, jsonType := "SELECT "+eventFreqColumns+" FROM eventFreqDB WHERE project = ? AND name = ?", ""
+	if db.Backend == StoragePostgres {
+		query, jsonType = query+" FOR UPDATE", "::jsonb"
+	}
+
+	var (
+		eventFreq                                                   model.EventFreq
+		hourCountString, weekdayCountString, weekdayHourCountString string
+	)
+	e := tx.QueryRow(rebind(db.Backend, query), db.Project, name).Scan(&eventFreq.ID, &eventFreq.Name, &eventFreq.TotalCount, &hourCountString, &weekdayCountString, &weekdayHourCountString)
+	if e == sql.ErrNoRows {
+		return nil
+	}
+	if e != nil {
+		return e
+	}
+
+	e = unmarshalEventFreqCounts(&eventFreq, hourCountString, weekdayCountString, weekdayHourCountString)
+	if e != nil {
+		return e
+	}
+
+	subtractEventFreqCounts(&eventFreq, totalCount, hourlyCounts)
+
+	args := []interface{}{eventFreq.TotalCount}
+	for _, distribution := range []interface{}{eventFreq.HourCount, eventFreq.WeekdayCount, eventFreq.WeekdayHourCount} {
+		distributionBytes, e := json.Marshal(distribution)
+		if e != nil {
+			return e
+		}
+		args = append(args, string(distributionBytes))
+	}
+	args = append(args, eventFreq.ID)
+
+	_, e = tx.Exec(rebind(db.Backend, "UPDATE eventFreqDB SET count = ?, hour_count = ?"+jsonType+", weekday_count = ?"+jsonType+", weekday_hour_count = ?"+jsonType+" WHERE id = ?"), args...)
+	if e != nil {
+		return e
+	}
+
+	return nil
+}
+
+func (db EventRetentionDB) subtractEventRollups(tx *sql.Tx, name string, dailyCounts []model.Event) (err error) {
+	stmt, e := tx.Prepare(rebind(db.Backend, `UPDATE eventRollupDB SET count = CASE WHEN count > ? THEN count - ? ELSE 0 END
+		WHERE project = ? AND name = ? AND period = ? AND period_start = ?`))
+	if e != nil {
+		return e
+	}
+
+	for key, count := range rollupCounts(dailyCounts) {
+		_, e = stmt.Exec(count, count, db.Project, name, key.period, key.start)
+		if e != nil {
+			_ = stmt.Close()
+			return e
+		}
+	}
+
+	e = stmt.Close()
+	if e != nil {
+		return e
+	}
+
+	_, e = tx.Exec(rebind(db.Backend, "DELETE FROM eventRollupDB WHERE project = ? AND name = ? AND count = 0"), db.Project, name)
+	if e != nil {
+		return e
+	}
+
+	return nil
+}
+
+// The daily rows recorded before the hourly counts were kept are only subtracted from the total count.
+func subtractEventFreqCounts(eventFreq *model.EventFreq, totalCount uint64, hourlyCounts []model.EventPropertyCount) {
+	subtract := func(value *uint64, count uint64) {
+		if count > *value {
+			*value = 0
+			return
+		}
+		*value -= count
+	}
+
+	subtract(&eventFreq.TotalCount, totalCount)
+	for _, count := range hourlyCounts {
+		day, e := time.Parse("2006-01-02", count.Date)
+		if e != nil || count.Hour >= 24 {
+			continue
+		}
+		weekday := day.Weekday()
+
+		subtract(&eventFreq.HourCount[count.Hour], count.Count)
+		subtract(&eventFreq.WeekdayCount[weekday], count.Count)
+		subtract(&eventFreq.WeekdayHourCount[weekday][count.Hour], count.Count)
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"eventTracker/internal/model"
+	"testing"
+	"time"
+)
+
+func TestPruneEventsRollups(t *testing.T) {
+	for _, backend := range []string{StorageSQLite, StoragePostgres, StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			storage := openTestStorage(t, backend)
+
+			// 2021-01-01 is a Friday, so the first two days are in the week of 2020-12-28, and the third
+			// one in the week of 2021-01-04.
+			occurrences := []model.EventOccurrence{
+				{Name: "login", Count: 1, Date: time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)},
+				{Name: "login", Count: 2, Date: time.Date(2021, 1, 3, 10, 0, 0, 0, time.UTC)},
+				{Name: "login", Count: 4, Date: time.Date(2021, 1, 4, 10, 0, 0, 0, time.UTC)},
+			}
+			if err := storage.EventIngestHandler.IngestEvents(occurrences); err != nil {
+				t.Fatal(err)
+			}
+
+			rollups := func(period string) map[string]uint64 {
+				events, err := storage.EventRollupDBHandler.GetEventRollups("login", period, "", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				counts := map[string]uint64{}
+				for _, event := range events {
+					counts[event.Date] = event.Count
+				}
+				return counts
+			}
+
+			pruned, err := storage.EventRetentionDBHandler.PruneEvents("login", "2021-01-02", 10)
+			if err != nil || pruned != 1 {
+				t.Fatalf("pruned %d rows, %v, want 1", pruned, err)
+			}
+			if weeks := rollups(RollupWeek); len(weeks) != 2 || weeks["2020-12-28"] != 2 || weeks["2021-01-04"] != 4 {
+				t.Fatalf("weeks = %v, want 2 occurrences in the week of 2020-12-28 and 4 in the next one", weeks)
+			}
+			if months := rollups(RollupMonth); len(months) != 1 || months["2021-01-01"] != 6 {
+				t.Fatalf("months = %v, want 6 occurrences in January", months)
+			}
+
+			pruned, err = storage.EventRetentionDBHandler.PruneEvents("login", "2021-01-04", 10)
+			if err != nil || pruned != 1 {
+				t.Fatalf("pruned %d rows, %v, want 1", pruned, err)
+			}
+			if weeks := rollups(RollupWeek); len(weeks) != 1 || weeks["2021-01-04"] != 4 {
+				t.Fatalf("weeks = %v, want the week of 2020-12-28 deleted and 4 occurrences in the next one", weeks)
+			}
+			if years := rollups(RollupYear); len(years) != 1 || years["2021-01-01"] != 4 {
+				t.Fatalf("years = %v, want 4 occurrences in 2021", years)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+	"time"
+)
+
+// The days aren't stored in eventRollupDB, they are the daily rows of eventDB.
+const (
+	RollupDay   = "day"
+	RollupWeek  = "week"
+	RollupMonth = "month"
+	RollupYear  = "year"
+)
+
+var rollupPeriods = []string{RollupWeek, RollupMonth, RollupYear}
+
+type EventRollupDBHandler interface {
+	// GetEventRollups reads every event when name is empty, dated on the first day of their period.
+	GetEventRollups(name, period, startDate, endDate string) (retrievedEvents []model.Event, err error)
+	DeleteEventRollups(name string) (err error)
+	ForProject(project string) EventRollupDBHandler
+}
+
+type EventRollupDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db EventRollupDB) ForProject(project string) EventRollupDBHandler {
+	db.Project = project
+	return db
+}
+
+const upsertEventRollupQuery = `INSERT INTO eventRollupDB (project, name, period, period_start, count) VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (project, name, period, period_start) DO UPDATE SET count = eventRollupDB.count + excluded.count`
+
+type rollupKey struct {
+	period, start string
+}
+
+// The rows whose date isn't in the YYYY-MM-DD format aren't in any rollup.
+func rollupCounts(dailyCounts []model.Event) map[rollupKey]uint64 {
+	counts := map[rollupKey]uint64{}
+	for _, event := range dailyCounts {
+		date, e := time.Parse("2006-01-02", event.Date)
+		if e != nil {
+			continue
+		}
+
+		for _, period := range rollupPeriods {
+			counts[rollupKey{period: period, start: rollupStart(date, period)}] += event.Count
+		}
+	}
+
+	return counts
+}
+
+func rollupStart(date time.Time, period string) string {
+	switch period {
+	case RollupWeek:
+		daysSinceMonday := (int(date.Weekday()) + 6) % 7
+		return time.Date(date.Year(), date.Month(), date.Day()-daysSinceMonday, 0, 0, 0, 0, date.Location()).Format("2006-01-02")
+	case RollupMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location()).Format("2006-01-02")
+	case RollupYear:
+		return time.Date(date.Year(), 1, 1, 0, 0, 0, 0, date.Location()).Format("2006-01-02")
+	default:
+		return date.Format("2006-01-02")
+	}
+}
+
+func (db EventRollupDB) GetEventRollups(name, period, startDate, endDate string) (retrievedEvents []model.Event, err error) {
+	query, column, args := "SELECT name, period_start, count FROM eventRollupDB WHERE project = ? AND period = ?", "period_start", []interface{}{db.Project, period}
+	if period == RollupDay {
+		query, column, args = "SELECT name, date, count FROM eventDB WHERE project = ?", "date", []interface{}{db.Project}
+	}
+
+	if name != "" {
+		query += " AND name = ?"
+		args = append(args, name)
+	}
+	if startDate != "" {
+		query += " AND " + column + " >= ?"
+		args = append(args, startDate)
+	}
+	if endDate != "" {
+		query += " AND " + column + " <= ?"
+		args = append(args, endDate)
+	}
+	query += " ORDER BY " + column + ", name"
+
+	rows, e := db.Database.Query(rebind(db.Backend, query), args...)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var event model.Event
+
+		e = rows.Scan(&event.Name, &event.Date, &event.Count)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedEvents = append(retrievedEvents, event)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedEvents, nil
+}
+
+func (db EventRollupDB) DeleteEventRollups(name string) (err error) {
+	_, e := db.Database.Exec(rebind(db.Backend, "DELETE FROM eventRollupDB WHERE project = ? AND name = ?"), db.Project, name)
+	if e != nil {
+		return e
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// DefaultProject holds the events recorded before the projects were introduced.
+const DefaultProject = "default"
+
+// Storage groups the handlers of one backend. Database is nil for the memory backend.
+type Storage struct {
+	Backend            string
+	Database           *sql.DB
+	EventDBHandler     EventDBHandler
+	EventFreqDBHandler EventFreqDBHandler
+	EventIngestHandler EventIngestHandler
+
+	EventPropertyDBHandler   EventPropertyDBHandler
+	EventOccurrenceDBHandler EventOccurrenceDBHandler
+	EventRetentionDBHandler  EventRetentionDBHandler
+	EventRollupDBHandler     EventRollupDBHandler
+	EventUniqueDBHandler     EventUniqueDBHandler
+	EventValueDBHandler      EventValueDBHandler
+	APIKeyDBHandler          APIKeyDBHandler
+	AlertRuleDBHandler       AlertRuleDBHandler
+}
+
+func OpenStorage(storage, dsn string, seed bool) (Storage, error) {
+	opened, e := openStorage(storage, dsn, seed)
+	if e != nil {
+		return Storage{}, e
+	}
+
+	return instrument(opened), nil
+}
+
+func openStorage(storage, dsn string, seed bool) (Storage, error) {
+	switch storage {
+	case StorageSQLite, StoragePostgres:
+		driver := "postgres"
+		if storage == StorageSQLite {
+			driver = "sqlite3"
+			if dsn == "" {
+				dsn = "./db.db"
+			}
+		}
+
+		database, e := sql.Open(driver, dsn)
+		if e != nil {
+			return Storage{}, e
+		}
+		if storage == StorageSQLite {
+			// SQLite allows a single writer, other connections would fail with "database is locked".
+			database.SetMaxOpenConns(1)
+		}
+
+		return Storage{
+			Backend:            storage,
+			Database:           database,
+			EventDBHandler:     EventDB{Database: database, Backend: storage, Project: DefaultProject},
+			EventFreqDBHandler: EventFreqDB{Database: database, Backend: storage, Project: DefaultProject},
+			EventIngestHandler: EventIngestDB{Database: database, Backend: storage, Project: DefaultProject},
+
+			EventPropertyDBHandler:   EventPropertyDB{Database: database, Backend: storage, Project: DefaultProject},
+			EventOccurrenceDBHandler: EventOccurrenceDB{Database: database, Backend: storage, Project: DefaultProject},
+			EventRetentionDBHandler:  EventRetentionDB{Database: database, Backend: storage, Project: DefaultProject},
+			EventRollupDBHandler:     EventRollupDB{Database: database, Backend: storage, Project: DefaultProject},
+			EventUniqueDBHandler:     EventUniqueDB{Database: database, Backend: storage, Project: DefaultProject},
+			EventValueDBHandler:      EventValueDB{Database: database, Backend: storage, Project: DefaultProject},
+			APIKeyDBHandler:          APIKeyDB{Database: database, Backend: storage, Project: DefaultProject},
+			AlertRuleDBHandler:       AlertRuleDB{Database: database, Backend: storage, Project: DefaultProject},
+		}, nil
+	case StorageMemory:
+		store := NewMemoryStore()
+		if seed {
+			store.Seed()
+		}
+
+		return Storage{
+			Backend:            storage,
+			EventDBHandler:     MemoryEventDB{Store: store},
+			EventFreqDBHandler: MemoryEventFreqDB{Store: store},
+			EventIngestHandler: MemoryEventIngestDB{Store: store},
+
+			EventPropertyDBHandler:   MemoryEventPropertyDB{Store: store},
+			EventOccurrenceDBHandler: MemoryEventOccurrenceDB{Store: store},
+			EventRetentionDBHandler:  MemoryEventRetentionDB{Store: store},
+			EventRollupDBHandler:     MemoryEventRollupDB{Store: store},
+			EventUniqueDBHandler:     MemoryEventUniqueDB{Store: store},
+			EventValueDBHandler:      MemoryEventValueDB{Store: store},
+			APIKeyDBHandler:          MemoryAPIKeyDB{Store: store, Project: DefaultProject},
+			AlertRuleDBHandler:       MemoryAlertRuleDB{Store: store, Project: DefaultProject},
+		}, nil
+	default:
+		return Storage{}, errors.New(fmt.Sprintf(model.ErrUnknownStorage.Error(), storage))
+	}
+}
+
+func rebind(backend, query string) string {
+	if backend != StoragePostgres {
+		return query
+	}
+
+	var (
+		builder strings.Builder
+		n       int
+	)
+	for _, r := range query {
+		if r == '?' {
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+)
+
+type EventUniqueDBHandler interface {
+	// GetEventUniques reads every event when name is empty, and empty dates leave the range open.
+	GetEventUniques(name, startDate, endDate string) (retrievedRegisters []model.EventUniqueRegister, err error)
+	DeleteEventUniques(name string) (err error)
+	ForProject(project string) EventUniqueDBHandler
+}
+
+type EventUniqueDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db EventUniqueDB) ForProject(project string) EventUniqueDBHandler {
+	db.Project = project
+	return db
+}
+
+// The registers only ever grow, so concurrent ingestions can't lower them.
+const upsertEventUniqueQuery = `INSERT INTO eventUniqueDB (project, name, date, register, rank) VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (project, name, date, register) DO UPDATE SET rank = excluded.rank WHERE excluded.rank > eventUniqueDB.rank`
+
+func (db EventUniqueDB) GetEventUniques(name, startDate, endDate string) (retrievedRegisters []model.EventUniqueRegister, err error) {
+	query := "SELECT name, date, register, rank FROM eventUniqueDB WHERE project = ?"
+	args := []interface{}{db.Project}
+	if name != "" {
+		query += " AND name = ?"
+		args = append(args, name)
+	}
+	if startDate != "" {
+		query += " AND date >= ?"
+		args = append(args, startDate)
+	}
+	if endDate != "" {
+		query += " AND date <= ?"
+		args = append(args, endDate)
+	}
+	query += " ORDER BY name, date"
+
+	rows, e := db.Database.Query(rebind(db.Backend, query), args...)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var register model.EventUniqueRegister
+
+		e = rows.Scan(&register.Name, &register.Date, &register.Register, &register.Rank)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedRegisters = append(retrievedRegisters, register)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedRegisters, nil
+}
+
+func (db EventUniqueDB) DeleteEventUniques(name string) (err error) {
+	_, e := db.Database.Exec(rebind(db.Backend, "DELETE FROM eventUniqueDB WHERE project = ? AND name = ?"), db.Project, name)
+	if e != nil {
+		return e
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"database/sql"
+	"eventTracker/internal/model"
+	"time"
+)
+
+type EventValueDBHandler interface {
+	// GetEventValues and GetEventValueBuckets read the hours from start (included) to end (excluded).
+	GetEventValues(name string, start, end time.Time) (retrievedStats []model.EventValueStats, err error)
+	GetEventValueBuckets(name string, start, end time.Time) (retrievedBuckets []model.EventValueBucket, err error)
+	DeleteEventValues(name string) (err error)
+	ForProject(project string) EventValueDBHandler
+}
+
+type EventValueDB struct {
+	Database *sql.DB
+	Backend  string
+	Project  string
+}
+
+func (db EventValueDB) ForProject(project string) EventValueDBHandler {
+	db.Project = project
+	return db
+}
+
+func upsertEventValueQuery(backend string) string {
+	least, greatest := "MIN", "MAX"
+	if backend == StoragePostgres {
+		least, greatest = "LEAST", "GREATEST"
+	}
+
+	return `INSERT INTO eventValueDB (project, name, hour, count, total, minimum, maximum) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (project, name, hour) DO UPDATE SET
+			count = eventValueDB.count + excluded.count,
+			total = eventValueDB.total + excluded.total,
+			minimum = ` + least + `(eventValueDB.minimum, excluded.minimum),
+			maximum = ` + greatest + `(eventValueDB.maximum, excluded.maximum)`
+}
+
+const upsertEventValueBucketQuery = `INSERT INTO eventValueBucketDB (project, name, hour, bucket, count) VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (project, name, hour, bucket) DO UPDATE SET count = eventValueBucketDB.count + excluded.count`
+
+func hourKey(date time.Time) string {
+	return date.UTC().Truncate(time.Hour).Format(time.RFC3339)
+}
+
+func (db EventValueDB) hourRange(name string, start, end time.Time) (string, []interface{}) {
+	return " WHERE project = ? AND name = ? AND hour >= ? AND hour < ?",
+		[]interface{}{db.Project, name, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339)}
+}
+
+func (db EventValueDB) GetEventValues(name string, start, end time.Time) (retrievedStats []model.EventValueStats, err error) {
+	conditions, args := db.hourRange(name, start, end)
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT name, hour, count, total, minimum, maximum FROM eventValueDB"+conditions+" ORDER BY hour"), args...)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var (
+			stats model.EventValueStats
+			hour  string
+		)
+
+		e = rows.Scan(&stats.Name, &hour, &stats.Count, &stats.Total, &stats.Min, &stats.Max)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		stats.Time, e = time.Parse(time.RFC3339, hour)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedStats = append(retrievedStats, stats)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedStats, nil
+}
+
+func (db EventValueDB) GetEventValueBuckets(name string, start, end time.Time) (retrievedBuckets []model.EventValueBucket, err error) {
+	conditions, args := db.hourRange(name, start, end)
+	rows, e := db.Database.Query(rebind(db.Backend, "SELECT name, hour, bucket, count FROM eventValueBucketDB"+conditions+" ORDER BY hour, bucket"), args...)
+	if e != nil {
+		return nil, e
+	}
+
+	for rows.Next() {
+		var (
+			bucket model.EventValueBucket
+			hour   string
+		)
+
+		e = rows.Scan(&bucket.Name, &hour, &bucket.Bucket, &bucket.Count)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		bucket.Time, e = time.Parse(time.RFC3339, hour)
+		if e != nil {
+			_ = rows.Close()
+			return nil, e
+		}
+
+		retrievedBuckets = append(retrievedBuckets, bucket)
+	}
+
+	e = rows.Close()
+	if e != nil {
+		return nil, e
+	}
+
+	return retrievedBuckets, nil
+}
+
+func (db EventValueDB) DeleteEventValues(name string) (err error) {
+	for _, table := range []string{"eventValueDB", "eventValueBucketDB"} {
+		_, e := db.Database.Exec(rebind(db.Backend, "DELETE FROM "+table+" WHERE project = ? AND name = ?"), db.Project, name)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package ddsketch
+
+import (
+	"math"
+	"sort"
+)
+
+const RelativeAccuracy = 0.01
+
+var (
+	gamma        = (1 + RelativeAccuracy) / (1 - RelativeAccuracy)
+	logGamma     = math.Log(gamma)
+	keyMagnitude = 1 << 20
+)
+
+// The keys are offset so that the ones of the positive and negative values never meet, whatever their magnitude.
+func Key(value float64) int {
+	if value == 0 {
+		return 0
+	}
+
+	key := int(math.Ceil(math.Log(math.Abs(value))/logGamma)) + keyMagnitude
+	if value < 0 {
+		return -key
+	}
+
+	return key
+}
+
+func Value(key int) float64 {
+	if key == 0 {
+		return 0
+	}
+
+	index := key
+	if key < 0 {
+		index = -key
+	}
+	value := 2 * math.Pow(gamma, float64(index-keyMagnitude)) / (gamma + 1)
+	if key < 0 {
+		return -value
+	}
+
+	return value
+}
+
+type Sketch struct {
+	counts map[int]uint64
+	total  uint64
+}
+
+func New() *Sketch {
+	return &Sketch{counts: map[int]uint64{}}
+}
+
+func (s *Sketch) Add(value float64, count uint64) {
+	s.AddKey(Key(value), count)
+}
+
+func (s *Sketch) AddKey(key int, count uint64) {
+	if count == 0 {
+		return
+	}
+
+	s.counts[key] += count
+	s.total += count
+}
+
+func (s *Sketch) Merge(other *Sketch) {
+	for key, count := range other.counts {
+		s.AddKey(key, count)
+	}
+}
+
+func (s *Sketch) Count() uint64 {
+	return s.total
+}
+
+// Quantile is the value of rank q*(count-1), and 0 for an empty sketch.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.total == 0 {
+		return 0
+	}
+
+	keys := make([]int, 0, len(s.counts))
+	for key := range s.counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return Value(keys[i]) < Value(keys[j])
+	})
+
+	rank := uint64(q * float64(s.total-1))
+	var seen uint64
+	for _, key := range keys {
+		seen += s.counts[key]
+		if seen > rank {
+			return Value(key)
+		}
+	}
+
+	return Value(keys[len(keys)-1])
+}
@@ -0,0 +1,107 @@
+package ddsketch
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+var testQuantiles = []float64{0, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 1}
+
+// checkQuantiles checks that the quantiles of a sketch are within RelativeAccuracy of the values of
+// their rank.
+func checkQuantiles(t *testing.T, sketch *Sketch, values []float64) {
+	t.Helper()
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	for _, q := range testQuantiles {
+		want := sorted[int(q*float64(len(sorted)-1))]
+		// A little more than RelativeAccuracy, for the rounding of the logarithms at the bounds of a bucket.
+		if got := sketch.Quantile(q); math.Abs(got-want) > RelativeAccuracy*math.Abs(want)*(1+1e-9) {
+			t.Fatalf("the quantile %v is %v, want %v within %v%%", q, got, want, 100*RelativeAccuracy)
+		}
+	}
+}
+
+func TestQuantileAccuracy(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	generate := func(n int, value func() float64) []float64 {
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = value()
+		}
+		return values
+	}
+
+	for _, test := range []struct {
+		name   string
+		values []float64
+	}{
+		{"positive", generate(10000, func() float64 { return math.Exp(random.NormFloat64()*3 + 5) })},
+		{"negative", generate(10000, func() float64 { return -math.Exp(random.NormFloat64() * 4) })},
+		{"below 1", generate(10000, func() float64 { return random.Float64() })},
+		{"tiny and huge", generate(10000, func() float64 { return math.Pow(10, random.Float64()*600-300) })},
+		{"zeros", generate(10000, func() float64 {
+			if random.Intn(3) == 0 {
+				return 0
+			}
+			return random.NormFloat64() * 100
+		})},
+		{"single value", []float64{42.42}},
+		{"only zeros", []float64{0, 0, 0}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sketch := New()
+			for _, value := range test.values {
+				sketch.Add(value, 1)
+			}
+			if sketch.Count() != uint64(len(test.values)) {
+				t.Fatalf("the sketch counts %d values, want %d", sketch.Count(), len(test.values))
+			}
+
+			checkQuantiles(t, sketch, test.values)
+		})
+	}
+}
+
+func TestQuantileCounts(t *testing.T) {
+	sketch := New()
+	sketch.Add(-5, 1)
+	sketch.Add(0.5, 2)
+	sketch.Add(300, 7)
+	sketch.Add(1, 0)
+
+	checkQuantiles(t, sketch, []float64{-5, 0.5, 0.5, 300, 300, 300, 300, 300, 300, 300})
+	if empty := New(); empty.Quantile(0.5) != 0 {
+		t.Fatalf("the median of an empty sketch is %v", empty.Quantile(0.5))
+	}
+}
+
+func TestMergeIsTheSketchOfTheUnion(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	a, b, union := New(), New(), New()
+	var values []float64
+	for i := 0; i < 20000; i++ {
+		value := random.NormFloat64() * 1000
+		if i%2 == 0 {
+			a.Add(value, 1)
+		} else {
+			b.Add(value, 1)
+		}
+		union.Add(value, 1)
+		values = append(values, value)
+	}
+
+	a.Merge(b)
+	if a.Count() != union.Count() || len(a.counts) != len(union.counts) {
+		t.Fatalf("the merged sketch has %d values in %d buckets, want %d in %d", a.Count(), len(a.counts), union.Count(), len(union.counts))
+	}
+	for key, count := range union.counts {
+		if a.counts[key] != count {
+			t.Fatalf("the merged sketch has %d values in bucket %d, want %d", a.counts[key], key, count)
+		}
+	}
+	checkQuantiles(t, a, values)
+}
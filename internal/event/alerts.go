@@ -0,0 +1,271 @@
+package event
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The conditions are strict, so a below rule with a threshold of 0 could never fire.
+const (
+	AlertAbove = "above"
+	AlertBelow = "below"
+
+	AlertOK       = "ok"
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+	AlertTest     = "test"
+)
+
+type AlertNotifier interface {
+	Notify(rule model.AlertRule, notification model.AlertNotification) error
+}
+
+// AlertDispatcher delivers the notifications from a bounded queue, so that a slow webhook doesn't hold up
+// the evaluation. A rule has at most one notification queued or being delivered.
+type AlertDispatcher struct {
+	notifier AlertNotifier
+	queue    chan alertDelivery
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[alertRuleKey]struct{}
+}
+
+type alertRuleKey struct {
+	project string
+	ID      uint64
+}
+
+type alertDelivery struct {
+	rule         model.AlertRule
+	notification model.AlertNotification
+	delivered    func(err error)
+}
+
+func NewAlertDispatcher(notifier AlertNotifier, workers, queueSize int) *AlertDispatcher {
+	d := &AlertDispatcher{notifier: notifier, queue: make(chan alertDelivery, queueSize), pending: map[alertRuleKey]struct{}{}}
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+
+	return d
+}
+
+// Dispatch returns false when a notification of the rule is already queued.
+func (d *AlertDispatcher) Dispatch(rule model.AlertRule, notification model.AlertNotification, delivered func(err error)) (queued bool, err error) {
+	key := alertRuleKey{project: rule.Project, ID: rule.ID}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.pending[key]; ok {
+		return false, nil
+	}
+
+	d.wg.Add(1)
+	select {
+	case d.queue <- alertDelivery{rule: rule, notification: notification, delivered: delivered}:
+		d.pending[key] = struct{}{}
+		return true, nil
+	default:
+		d.wg.Done()
+		return false, model.ErrAlertQueueFull
+	}
+}
+
+func (d *AlertDispatcher) Wait() {
+	d.wg.Wait()
+}
+
+func (d *AlertDispatcher) work() {
+	for delivery := range d.queue {
+		e := d.notifier.Notify(delivery.rule, delivery.notification)
+
+		d.mu.Lock()
+		delete(d.pending, alertRuleKey{project: delivery.rule.Project, ID: delivery.rule.ID})
+		d.mu.Unlock()
+
+		delivery.delivered(e)
+		d.wg.Done()
+	}
+}
+
+func (es EventService) AlertRules(AlertRuleDBHandler db.AlertRuleDBHandler) (rules []model.AlertRule, err error) {
+	rules, e := AlertRuleDBHandler.GetAlertRules()
+	if e != nil {
+		return nil, e
+	}
+	if rules == nil {
+		rules = []model.AlertRule{}
+	}
+
+	return rules, nil
+}
+
+func (es EventService) AlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, ID uint64) (rule model.AlertRule, err error) {
+	return AlertRuleDBHandler.GetAlertRule(ID)
+}
+
+func (es EventService) CreateAlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, body model.AlertRuleBody, now time.Time) (rule model.AlertRule, err error) {
+	rule = model.AlertRule{State: AlertOK, CreatedAt: now.UTC().Format(time.RFC3339)}
+	applyAlertRuleBody(&rule, body)
+
+	return AlertRuleDBHandler.CreateAlertRule(rule)
+}
+
+func (es EventService) UpdateAlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, ID uint64, body model.AlertRuleBody) (rule model.AlertRule, err error) {
+	rule, e := AlertRuleDBHandler.GetAlertRule(ID)
+	if e != nil {
+		return model.AlertRule{}, e
+	}
+
+	applyAlertRuleBody(&rule, body)
+
+	return AlertRuleDBHandler.UpdateAlertRule(rule)
+}
+
+func (es EventService) DeleteAlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, ID uint64) (err error) {
+	return AlertRuleDBHandler.DeleteAlertRule(ID)
+}
+
+func applyAlertRuleBody(rule *model.AlertRule, body model.AlertRuleBody) {
+	rule.Name = body.Name
+	rule.Event = body.Event
+	rule.Condition = body.Condition
+	rule.Threshold = body.Threshold
+	rule.WindowMinutes = body.WindowMinutes
+	rule.WebhookURL = body.WebhookURL
+	if body.Secret != nil {
+		rule.Secret = *body.Secret
+	}
+}
+
+func NewAlertNotification(rule model.AlertRule, state string, now time.Time) model.AlertNotification {
+	return model.AlertNotification{
+		Project:       rule.Project,
+		RuleID:        rule.ID,
+		Rule:          rule.Name,
+		Event:         rule.Event,
+		Condition:     rule.Condition,
+		Threshold:     rule.Threshold,
+		WindowMinutes: rule.WindowMinutes,
+		State:         state,
+		Value:         rule.Value,
+		Time:          now.UTC().Format(time.RFC3339),
+	}
+}
+
+func alertWindowCount(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name string, windowMinutes uint64, now time.Time) (count uint64, err error) {
+	end := now.UTC().Truncate(time.Minute).Add(time.Minute)
+	start := end.Add(-time.Duration(windowMinutes) * time.Minute)
+
+	counts, e := EventOccurrenceDBHandler.GetEventOccurrences(name, start, end)
+	if e != nil {
+		return 0, e
+	}
+
+	for _, minuteCount := range counts {
+		count += minuteCount.Count
+	}
+
+	return count, nil
+}
+
+// A rule only changes state once its notification is delivered, so that a failed one is sent again.
+func (es EventService) EvaluateAlertRules(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, AlertRuleDBHandler db.AlertRuleDBHandler, dispatcher *AlertDispatcher, now time.Time) (queued int, err error) {
+	rules, e := AlertRuleDBHandler.GetAlertRules()
+	if e != nil {
+		return 0, e
+	}
+
+	evaluatedAt := now.UTC().Format(time.RFC3339)
+	for _, rule := range rules {
+		value, e := alertWindowCount(EventOccurrenceDBHandler, rule.Event, rule.WindowMinutes, now)
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+
+		firing := value > rule.Threshold
+		if rule.Condition == AlertBelow {
+			firing = value < rule.Threshold
+		}
+
+		state := AlertOK
+		if firing {
+			state = AlertFiring
+		}
+
+		e = AlertRuleDBHandler.UpdateAlertRuleState(rule.ID, rule.State, value, rule.ChangedAt, evaluatedAt)
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		if state == rule.State {
+			continue
+		}
+
+		rule.Value = value
+		notificationState := state
+		if state == AlertOK {
+			notificationState = AlertResolved
+		}
+
+		ruleID, newState := rule.ID, state
+		ok, e := dispatcher.Dispatch(rule, NewAlertNotification(rule, notificationState, now), func(e error) {
+			if e != nil {
+				println(errors.New(fmt.Sprintf(model.ErrNotifyWebhook.Error(), ruleID, e.Error())).Error())
+				return
+			}
+
+			e = AlertRuleDBHandler.UpdateAlertRuleState(ruleID, newState, value, evaluatedAt, evaluatedAt)
+			if e != nil && !errors.Is(e, model.ErrAlertRuleNotFound) {
+				println(fmt.Sprintf("Error updating the state of alert rule %d: %s", ruleID, e.Error()))
+			}
+		})
+		if e != nil && err == nil {
+			err = e
+		}
+		if ok {
+			queued++
+		}
+	}
+
+	return queued, err
+}
+
+func (es EventService) RunAlertRules(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, AlertRuleDBHandler db.AlertRuleDBHandler, dispatcher *AlertDispatcher, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		projects, e := AlertRuleDBHandler.GetAlertProjects()
+		if e != nil {
+			println(fmt.Sprintf("Error reading the projects with alert rules: %s", e.Error()))
+		}
+
+		for _, project := range projects {
+			queued, e := es.EvaluateAlertRules(EventOccurrenceDBHandler.ForProject(project), AlertRuleDBHandler.ForProject(project), dispatcher, time.Now())
+			if e != nil {
+				println(fmt.Sprintf("Error evaluating the alert rules of project %s: %s", project, e.Error()))
+			}
+			if queued > 0 {
+				println(fmt.Sprintf("Queued %d alert notifications of project %s", queued, project))
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
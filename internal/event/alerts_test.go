@@ -0,0 +1,198 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"eventTracker/internal/alerting"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEvaluateAlertRules(t *testing.T) {
+	storage, err := db.OpenStorage(db.StorageMemory, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu            sync.Mutex
+		notifications []model.AlertNotification
+		failing       bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var notification model.AlertNotification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Error(err)
+		}
+		notifications = append(notifications, notification)
+	}))
+	defer server.Close()
+	dispatcher := NewAlertDispatcher(&alerting.WebhookNotifier{Client: server.Client(), Attempts: 1}, 1, 1)
+
+	es := EventService{}
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	rule, err := es.CreateAlertRule(storage.AlertRuleDBHandler, model.AlertRuleBody{
+		Name: "no checkouts", Event: "checkout", Condition: AlertBelow, Threshold: 1, WindowMinutes: 60, WebhookURL: server.URL,
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluate := func(at time.Time, wantQueued int, wantState string) {
+		t.Helper()
+
+		queued, err := es.EvaluateAlertRules(storage.EventOccurrenceDBHandler, storage.AlertRuleDBHandler, dispatcher, at)
+		if err != nil || queued != wantQueued {
+			t.Fatalf("evaluating at %s queued %d, %v, want %d", at, queued, err, wantQueued)
+		}
+		dispatcher.Wait()
+
+		rule, err = storage.AlertRuleDBHandler.GetAlertRule(rule.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rule.State != wantState {
+			t.Fatalf("the rule is %s at %s, want %s", rule.State, at, wantState)
+		}
+	}
+
+	// No checkout in the last hour fires the rule, once.
+	evaluate(now, 1, AlertFiring)
+	evaluate(now.Add(time.Minute), 0, AlertFiring)
+	if notifications[0].State != AlertFiring || notifications[0].Value != 0 || notifications[0].Rule != "no checkouts" {
+		t.Fatalf("the notification is %+v, want the rule firing with no occurrences", notifications[0])
+	}
+
+	// A checkout resolves it, but not while the webhook fails.
+	err = es.CreateEvent(storage.EventIngestHandler, "checkout", 2, now.Add(2*time.Minute), nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing = true
+	evaluate(now.Add(3*time.Minute), 1, AlertFiring)
+	failing = false
+	evaluate(now.Add(4*time.Minute), 1, AlertOK)
+	if notifications[1].State != AlertResolved || notifications[1].Value != 2 {
+		t.Fatalf("the notification is %+v, want the rule resolved with 2 occurrences", notifications[1])
+	}
+
+	// The rule fires again once the checkout is out of the window.
+	evaluate(now.Add(61*time.Minute), 0, AlertOK)
+	evaluate(now.Add(62*time.Minute), 1, AlertFiring)
+	if len(notifications) != 3 {
+		t.Fatalf("the webhook got %d notifications, want 3", len(notifications))
+	}
+}
+
+func TestEvaluateAlertRulesDoesNotWaitForWebhooks(t *testing.T) {
+	storage, err := db.OpenStorage(db.StorageMemory, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+	}))
+	defer server.Close()
+	dispatcher := NewAlertDispatcher(&alerting.WebhookNotifier{Client: server.Client(), Attempts: 1}, 2, 2)
+
+	es := EventService{}
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	rules := map[string]model.AlertRule{}
+	for _, body := range []model.AlertRuleBody{
+		{Name: "slow", Event: "checkout", Condition: AlertBelow, Threshold: 1, WindowMinutes: 60, WebhookURL: server.URL + "/slow"},
+		{Name: "fast", Event: "checkout", Condition: AlertBelow, Threshold: 1, WindowMinutes: 60, WebhookURL: server.URL + "/fast"},
+	} {
+		rules[body.Name], err = es.CreateAlertRule(storage.AlertRuleDBHandler, body, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	state := func(name string) string {
+		t.Helper()
+
+		rule, err := storage.AlertRuleDBHandler.GetAlertRule(rules[name].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rule.State
+	}
+
+	// The slow webhook holds its notification, but neither the evaluation nor the other rule wait for it.
+	queued, err := es.EvaluateAlertRules(storage.EventOccurrenceDBHandler, storage.AlertRuleDBHandler, dispatcher, now)
+	if err != nil || queued != 2 {
+		t.Fatalf("the first evaluation queued %d, %v, want 2", queued, err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); state("fast") != AlertFiring; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the fast rule waited for the slow webhook")
+		}
+	}
+
+	// The slow rule isn't notified twice while its notification is being delivered.
+	queued, err = es.EvaluateAlertRules(storage.EventOccurrenceDBHandler, storage.AlertRuleDBHandler, dispatcher, now.Add(time.Minute))
+	if err != nil || queued != 0 {
+		t.Fatalf("the second evaluation queued %d, %v, want 0", queued, err)
+	}
+	if state("slow") != AlertOK {
+		t.Fatalf("the slow rule is %s before its webhook answered, want %s", state("slow"), AlertOK)
+	}
+
+	close(release)
+	dispatcher.Wait()
+	if state("slow") != AlertFiring {
+		t.Fatalf("the slow rule is %s once its webhook answered, want %s", state("slow"), AlertFiring)
+	}
+}
+
+func TestAlertDispatcherQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	dispatcher := NewAlertDispatcher(notifierFunc(func(model.AlertRule, model.AlertNotification) error {
+		<-release
+		return nil
+	}), 1, 1)
+	defer func() {
+		close(release)
+		dispatcher.Wait()
+	}()
+
+	delivered := func(error) {}
+	for ID := uint64(1); ID <= 2; ID++ {
+		if queued, err := dispatcher.Dispatch(model.AlertRule{ID: ID}, model.AlertNotification{}, delivered); !queued || err != nil {
+			t.Fatalf("dispatching rule %d got %v, %v", ID, queued, err)
+		}
+		// The first notification is taken by the worker, leaving the queue to the second one.
+		for ID == 1 && len(dispatcher.queue) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if queued, err := dispatcher.Dispatch(model.AlertRule{ID: 3}, model.AlertNotification{}, delivered); queued || !errors.Is(err, model.ErrAlertQueueFull) {
+		t.Fatalf("dispatching on a full queue got %v, %v, want %v", queued, err, model.ErrAlertQueueFull)
+	}
+	if queued, err := dispatcher.Dispatch(model.AlertRule{ID: 1}, model.AlertNotification{}, delivered); queued || err != nil {
+		t.Fatalf("dispatching a rule being delivered got %v, %v, want it skipped", queued, err)
+	}
+}
+
+type notifierFunc func(rule model.AlertRule, notification model.AlertNotification) error
+
+func (f notifierFunc) Notify(rule model.AlertRule, notification model.AlertNotification) error {
+	return f(rule, notification)
+}
@@ -0,0 +1,94 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"math"
+	"sort"
+	"time"
+)
+
+// madScale turns the median absolute deviation into the standard deviation of normally distributed counts.
+const madScale = 1.4826
+
+func (es EventService) EventAnomalies(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name string, end time.Time, hours, weeks int, threshold float64) (anomalies model.EventAnomalies, err error) {
+	location := end.Location()
+	endBucket := TruncateTime(end, IntervalHour)
+	firstBucket := endBucket.Add(-time.Duration(hours) * time.Hour)
+	baselineStart := firstBucket.AddDate(0, 0, -7*weeks)
+
+	counts, e := EventOccurrenceDBHandler.GetEventOccurrences(name, baselineStart, endBucket)
+	if e != nil {
+		return model.EventAnomalies{}, e
+	}
+
+	countByBucket := map[int64]uint64{}
+	for _, count := range counts {
+		countByBucket[TruncateTime(count.Time.In(location), IntervalHour).Unix()] += count.Count
+	}
+
+	anomalies = model.EventAnomalies{
+		Name:      name,
+		Start:     firstBucket.Format(time.RFC3339),
+		End:       endBucket.Format(time.RFC3339),
+		Weeks:     weeks,
+		Threshold: threshold,
+		Anomalies: []model.EventAnomaly{},
+	}
+
+	// Stepping in the location compares the same hour of the week across the changes of daylight saving time.
+	for bucket := firstBucket; bucket.Before(endBucket); bucket = NextBucket(bucket, IntervalHour) {
+		baseline := make([]uint64, weeks)
+		for week := range baseline {
+			baseline[week] = countByBucket[bucket.AddDate(0, 0, -7*(week+1)).Unix()]
+		}
+
+		expected, deviation := seasonalBaseline(baseline)
+		actual := countByBucket[bucket.Unix()]
+		score := (float64(actual) - expected) / deviation
+		if math.Abs(score) < threshold {
+			continue
+		}
+
+		anomalies.Anomalies = append(anomalies.Anomalies, model.EventAnomaly{
+			Time:     bucket.Format(time.RFC3339),
+			Actual:   actual,
+			Expected: expected,
+			Lower:    math.Max(0, expected-threshold*deviation),
+			Upper:    expected + threshold*deviation,
+			Score:    score,
+			Baseline: baseline,
+		})
+	}
+
+	return anomalies, nil
+}
+
+// The deviation is never less than the noise of a Poisson count nor than 1, so that flat baselines don't
+// flag every change.
+func seasonalBaseline(baseline []uint64) (expected, deviation float64) {
+	values := make([]float64, len(baseline))
+	for i, count := range baseline {
+		values[i] = float64(count)
+	}
+
+	expected = median(values)
+	for i, value := range values {
+		values[i] = math.Abs(value - expected)
+	}
+
+	deviation = math.Max(madScale*median(values), math.Max(math.Sqrt(expected), 1))
+
+	return expected, deviation
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+
+	return values[middle]
+}
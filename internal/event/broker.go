@@ -0,0 +1,156 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"path"
+	"sync"
+	"time"
+)
+
+// Broker never blocks on publishing, and drops the occurrences that don't fit in the buffer of a subscriber.
+type Broker struct {
+	bufferSize int
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+
+	// ingestion is read locked by PublishingIngestHandler from recording occurrences until publishing them.
+	ingestion sync.RWMutex
+}
+
+type Subscription struct {
+	project string
+	pattern string
+	events  chan model.EventOccurrence
+
+	mu      sync.Mutex
+	dropped uint64
+}
+
+func NewBroker(bufferSize int) *Broker {
+	return &Broker{bufferSize: bufferSize, subscribers: map[*Subscription]struct{}{}}
+}
+
+// Subscribe matches the pattern with path.Match, and an empty one matches every event.
+func (b *Broker) Subscribe(project, pattern string) (*Subscription, error) {
+	_, e := path.Match(pattern, "")
+	if e != nil {
+		return nil, model.ErrInvalidEventPattern
+	}
+
+	subscription := &Subscription{project: project, pattern: pattern, events: make(chan model.EventOccurrence, b.bufferSize)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[subscription] = struct{}{}
+
+	return subscription, nil
+}
+
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[subscription]; !ok {
+		return
+	}
+
+	delete(b.subscribers, subscription)
+	close(subscription.events)
+}
+
+func (b *Broker) Publish(project string, occurrences []model.EventOccurrence) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscription := range b.subscribers {
+		if subscription.project != project {
+			continue
+		}
+
+		for _, occurrence := range occurrences {
+			if !subscription.matches(occurrence.Name) {
+				continue
+			}
+
+			select {
+			case subscription.events <- occurrence:
+			default:
+				subscription.mu.Lock()
+				subscription.dropped++
+				subscription.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Snapshot runs read while no occurrence is being recorded, and holds the ingestion up.
+func (b *Broker) Snapshot(read func() error) error {
+	b.ingestion.Lock()
+	defer b.ingestion.Unlock()
+
+	return read()
+}
+
+func (s *Subscription) Events() <-chan model.EventOccurrence {
+	return s.events
+}
+
+func (s *Subscription) TakeDropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := s.dropped
+	s.dropped = 0
+
+	return dropped
+}
+
+func (s *Subscription) matches(name string) bool {
+	if s.pattern == "" {
+		return true
+	}
+
+	matched, _ := path.Match(s.pattern, name)
+	return matched
+}
+
+type PublishingIngestHandler struct {
+	db.EventIngestHandler
+	Broker  *Broker
+	Project string
+}
+
+func (h PublishingIngestHandler) IngestEvents(occurrences []model.EventOccurrence) (err error) {
+	h.Broker.ingestion.RLock()
+	defer h.Broker.ingestion.RUnlock()
+
+	e := h.EventIngestHandler.IngestEvents(occurrences)
+	if e != nil {
+		return e
+	}
+
+	h.Broker.Publish(h.Project, occurrences)
+
+	return nil
+}
+
+func (h PublishingIngestHandler) IngestEventsOnce(key model.IdempotencyKey, window time.Duration, occurrences []model.EventOccurrence) (err error) {
+	h.Broker.ingestion.RLock()
+	defer h.Broker.ingestion.RUnlock()
+
+	e := h.EventIngestHandler.IngestEventsOnce(key, window, occurrences)
+	if e != nil {
+		return e
+	}
+
+	h.Broker.Publish(h.Project, occurrences)
+
+	return nil
+}
+
+func (h PublishingIngestHandler) ForProject(project string) db.EventIngestHandler {
+	return PublishingIngestHandler{EventIngestHandler: h.EventIngestHandler.ForProject(project), Broker: h.Broker, Project: project}
+}
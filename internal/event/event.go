@@ -5,7 +5,6 @@ import (
 	"eventTracker/internal/db"
 	"eventTracker/internal/model"
 	"fmt"
-	"strconv"
 	"time"
 )
 
@@ -14,11 +13,38 @@ type EventServiceI interface {
  	EventsByDateRange(EventDBHandler db.EventDBHandler, startDate, endDate string) (events []model.Event, err error)
  	AllEvents(EventDBHandler db.EventDBHandler) (events []model.Event, err error)
  	EventByID(EventDBHandler db.EventDBHandler, ID uint64) (event model.Event, err error)
- 	CreateEvent(EventDBHandler db.EventDBHandler, EventDBFreqHandler db.EventFreqDBHandler, name string, count uint64, date time.Time) (err error)
- 	DeleteEvent(EventDBHandler db.EventDBHandler, EventDBFreqHandler db.EventFreqDBHandler, name string) (err error)
+ 	CreateEvent(EventIngestHandler db.EventIngestHandler, name string, count uint64, date time.Time, properties map[string]string, userID string, value *float64) (err error)
+ 	CreateEvents(EventIngestHandler db.EventIngestHandler, occurrences []model.EventOccurrence) (err error)
+ 	CreateEventOnce(EventIngestHandler db.EventIngestHandler, key model.IdempotencyKey, window time.Duration, name string, count uint64, date time.Time, properties map[string]string, userID string, value *float64) (err error)
+ 	DeleteEvent(EventDBHandler db.EventDBHandler, EventDBFreqHandler db.EventFreqDBHandler, EventPropertyDBHandler db.EventPropertyDBHandler, EventOccurrenceDBHandler db.EventOccurrenceDBHandler, EventRollupDBHandler db.EventRollupDBHandler, EventUniqueDBHandler db.EventUniqueDBHandler, EventValueDBHandler db.EventValueDBHandler, name string) (err error)
 	EventFrequencyByName(EventDBFreqHandler db.EventFreqDBHandler, name string) (eventFreq model.EventFreq, err error)
+	EventExists(EventDBFreqHandler db.EventFreqDBHandler, name string) (err error)
 	AllEventsFrequencies(EventDBFreqHandler db.EventFreqDBHandler) (events []model.EventFreq, err error)
 	AllEventsHistory(EventDBFreqHandler db.EventFreqDBHandler) (events []model.EventHistory, err error)
+	EventsByProperties(EventPropertyDBHandler db.EventPropertyDBHandler, query model.PropertyQuery, startDate, endDate string, location *time.Location) (events []model.Event, err error)
+	EventFrequenciesByProperties(EventPropertyDBHandler db.EventPropertyDBHandler, name string, query model.PropertyQuery, location *time.Location) (eventFreqs []model.EventFreq, err error)
+	EventSeries(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, EventRollupDBHandler db.EventRollupDBHandler, name string, start, end time.Time, interval string) (series model.EventSeries, err error)
+	EventAnomalies(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name string, end time.Time, hours, weeks int, threshold float64) (anomalies model.EventAnomalies, err error)
+	EventFunnel(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, steps []string, startDate, endDate string, location *time.Location) (funnel model.EventFunnel, err error)
+	EventValues(EventValueDBHandler db.EventValueDBHandler, name string, start, end time.Time, interval string, percentiles []float64) (values model.EventValues, err error)
+	EventUniques(EventUniqueDBHandler db.EventUniqueDBHandler, name, startDate, endDate, interval string) (uniques model.EventUniques, err error)
+	AddEventUniques(EventUniqueDBHandler db.EventUniqueDBHandler, events []model.Event, interval string) (err error)
+	EventsByInterval(EventRollupDBHandler db.EventRollupDBHandler, name, startDate, endDate, interval string) (events []model.Event, err error)
+	EventsInLocation(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name, startDate, endDate string, location *time.Location) (events []model.Event, err error)
+	EventFrequenciesInLocation(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name string, location *time.Location) (eventFreqs []model.EventFreq, err error)
+	LiveHourCount(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name string, hour time.Time) (count uint64, err error)
+	RetentionSettings(EventRetentionDBHandler db.EventRetentionDBHandler) (settings model.RetentionSettings, err error)
+	SetRetention(EventRetentionDBHandler db.EventRetentionDBHandler, name string, days uint64) (err error)
+	DeleteRetention(EventRetentionDBHandler db.EventRetentionDBHandler, name string) (err error)
+	PruneExpiredEvents(EventDBFreqHandler db.EventFreqDBHandler, EventRetentionDBHandler db.EventRetentionDBHandler, now time.Time, batchSize int) (pruned int, err error)
+	RunJanitor(EventDBFreqHandler db.EventFreqDBHandler, EventRetentionDBHandler db.EventRetentionDBHandler, interval time.Duration, batchSize int, stop <-chan struct{})
+	AlertRules(AlertRuleDBHandler db.AlertRuleDBHandler) (rules []model.AlertRule, err error)
+	AlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, ID uint64) (rule model.AlertRule, err error)
+	CreateAlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, body model.AlertRuleBody, now time.Time) (rule model.AlertRule, err error)
+	UpdateAlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, ID uint64, body model.AlertRuleBody) (rule model.AlertRule, err error)
+	DeleteAlertRule(AlertRuleDBHandler db.AlertRuleDBHandler, ID uint64) (err error)
+	EvaluateAlertRules(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, AlertRuleDBHandler db.AlertRuleDBHandler, dispatcher *AlertDispatcher, now time.Time) (queued int, err error)
+	RunAlertRules(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, AlertRuleDBHandler db.AlertRuleDBHandler, dispatcher *AlertDispatcher, interval time.Duration, stop <-chan struct{})
 }
 
 type EventService struct {}
@@ -59,57 +85,43 @@ func (es EventService) EventByID(EventDBHandler db.EventDBHandler, ID uint64) (e
 	return event, nil
 }
 
-func (es EventService) CreateEvent(EventDBHandler db.EventDBHandler, EventDBFreqHandler db.EventFreqDBHandler, name string, count uint64, date time.Time) (err error) {
-	dateYYYYmmdd := date.Format("2006-01-02")
-
-	event, e := EventDBHandler.GetEventByNameAndDate(name, dateYYYYmmdd)
-	if errors.Is(e, model.ErrEventNotFound) {
-		println(fmt.Sprintf("Creating new event %s", name))
-
-		e := EventDBHandler.CreateEvent(name, count, dateYYYYmmdd)
-		if e != nil {
-			return errors.New(fmt.Sprintf(model.ErrInsertEventDB.Error(), e.Error()))
-		}
-	} else if e != nil {
-		return errors.New(fmt.Sprintf("error getting event by name and date: %s", e))
-	} else {
-		println(fmt.Sprintf("Updating event %s", name))
+// The daily count and the hour frequency are updated in a single transaction.
+func (es EventService) CreateEvent(EventIngestHandler db.EventIngestHandler, name string, count uint64, date time.Time, properties map[string]string, userID string, value *float64) (err error) {
+	return es.CreateEvents(EventIngestHandler, []model.EventOccurrence{{Name: name, Count: count, Date: date, Properties: properties, UserID: userID, Value: value}})
+}
 
-		e := EventDBHandler.UpdateEvent(event.ID, count)
-		if e != nil {
-			return errors.New(fmt.Sprintf(model.ErrUpdateEventDB.Error(), e.Error()))
-		}
+// The occurrences are stored in UTC, whatever the location of their dates.
+func (es EventService) CreateEvents(EventIngestHandler db.EventIngestHandler, occurrences []model.EventOccurrence) (err error) {
+	utcOccurrences := make([]model.EventOccurrence, len(occurrences))
+	for i, occurrence := range occurrences {
+		occurrence.Date = occurrence.Date.UTC()
+		utcOccurrences[i] = occurrence
 	}
 
-	hour := date.Format("15")
-	hourUint, e := strconv.ParseUint(hour, 10, 8)
+	e := EventIngestHandler.IngestEvents(utcOccurrences)
 	if e != nil {
-		return model.ErrParseHour
+		return errors.New(fmt.Sprintf(model.ErrIngestEvent.Error(), e.Error()))
 	}
 
-	eventFreq, e := EventDBFreqHandler.GetEventByName(name)
-	if errors.Is(e, model.ErrEventNotFound) {
-		println(fmt.Sprintf("Creating new event %s frequency", name))
+	return nil
+}
 
-		e = EventDBFreqHandler.CreateEvent(name, count, hourUint)
-		if e != nil {
-			return errors.New(fmt.Sprintf(model.ErrInsertEventFreqDB.Error(), e.Error()))
-		}
-	} else if e != nil{
-		return errors.New(fmt.Sprintf("error getting event freq by name: %s", e))
-	} else {
-		println(fmt.Sprintf("Updating event %s frequency", name))
+// CreateEventOnce returns model.ErrIdempotencyKeyReused when the key was used by a different request.
+func (es EventService) CreateEventOnce(EventIngestHandler db.EventIngestHandler, key model.IdempotencyKey, window time.Duration, name string, count uint64, date time.Time, properties map[string]string, userID string, value *float64) (err error) {
+	occurrences := []model.EventOccurrence{{Name: name, Count: count, Date: date.UTC(), Properties: properties, UserID: userID, Value: value}}
 
-		e = EventDBFreqHandler.UpdateEvent(eventFreq.ID, count, hourUint)
-		if e != nil {
-			return errors.New(fmt.Sprintf(model.ErrUpdateEventFreqDB.Error(), e.Error()))
-		}
+	e := EventIngestHandler.IngestEventsOnce(key, window, occurrences)
+	if errors.Is(e, model.ErrDuplicateRequest) || errors.Is(e, model.ErrIdempotencyKeyReused) {
+		return e
+	}
+	if e != nil {
+		return errors.New(fmt.Sprintf(model.ErrIngestEvent.Error(), e.Error()))
 	}
 
 	return nil
 }
 
-func (es EventService) DeleteEvent(EventDBHandler db.EventDBHandler, EventDBFreqHandler db.EventFreqDBHandler, name string) (err error) {
+func (es EventService) DeleteEvent(EventDBHandler db.EventDBHandler, EventDBFreqHandler db.EventFreqDBHandler, EventPropertyDBHandler db.EventPropertyDBHandler, EventOccurrenceDBHandler db.EventOccurrenceDBHandler, EventRollupDBHandler db.EventRollupDBHandler, EventUniqueDBHandler db.EventUniqueDBHandler, EventValueDBHandler db.EventValueDBHandler, name string) (err error) {
 	IDsToDelete, e := EventDBHandler.GetEventsIDsByName(name)
 	if errors.Is(e, model.ErrEventNotFound) {
 		return errors.New(fmt.Sprintf(model.ErrDoesntExistEventDB.Error(), name))
@@ -138,6 +150,31 @@ func (es EventService) DeleteEvent(EventDBHandler db.EventDBHandler, EventDBFreq
 		}
 	}
 
+	e = EventPropertyDBHandler.DeleteEventProperties(name)
+	if e != nil {
+		return errors.New(fmt.Sprintf(model.ErrDeleteEventPropertyDB.Error(), e.Error()))
+	}
+
+	e = EventOccurrenceDBHandler.DeleteEventOccurrences(name)
+	if e != nil {
+		return errors.New(fmt.Sprintf(model.ErrDeleteOccurrenceDB.Error(), e.Error()))
+	}
+
+	e = EventRollupDBHandler.DeleteEventRollups(name)
+	if e != nil {
+		return errors.New(fmt.Sprintf(model.ErrDeleteRollupDB.Error(), e.Error()))
+	}
+
+	e = EventUniqueDBHandler.DeleteEventUniques(name)
+	if e != nil {
+		return errors.New(fmt.Sprintf(model.ErrDeleteUniqueDB.Error(), e.Error()))
+	}
+
+	e = EventValueDBHandler.DeleteEventValues(name)
+	if e != nil {
+		return errors.New(fmt.Sprintf(model.ErrDeleteValueDB.Error(), e.Error()))
+	}
+
 	return nil
 }
 
@@ -166,4 +203,14 @@ func (es EventService) EventFrequencyByName(EventDBFreqHandler db.EventFreqDBHan
 	}
 
 	return eventFreq, nil
+}
+
+// EventExists passes the errors of the storage other than not found through as they are.
+func (es EventService) EventExists(EventDBFreqHandler db.EventFreqDBHandler, name string) (err error) {
+	_, e := EventDBFreqHandler.GetEventByName(name)
+	if errors.Is(e, model.ErrEventNotFound) {
+		return model.ErrEventNotFound
+	}
+
+	return e
 }
\ No newline at end of file
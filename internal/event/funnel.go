@@ -0,0 +1,75 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"time"
+)
+
+// EventFunnel takes the steps recorded within the same minute as being in order.
+func (es EventService) EventFunnel(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, steps []string, startDate, endDate string, location *time.Location) (funnel model.EventFunnel, err error) {
+	start, e := time.ParseInLocation("2006-01-02", startDate, location)
+	if e != nil {
+		return model.EventFunnel{}, e
+	}
+	end, e := time.ParseInLocation("2006-01-02", endDate, location)
+	if e != nil {
+		return model.EventFunnel{}, e
+	}
+	if end.Before(start) {
+		return model.EventFunnel{}, model.ErrInvalidRange
+	}
+
+	users, e := EventOccurrenceDBHandler.GetEventUsers(steps, start, end.AddDate(0, 0, 1))
+	if e != nil {
+		return model.EventFunnel{}, e
+	}
+
+	stepIndex := map[string]int{}
+	for i, step := range steps {
+		stepIndex[step] = i
+	}
+
+	// The minutes come sorted by user and then by minute, so each user is a single pass.
+	reached := make([]uint64, len(steps))
+	next := 0
+	for i := 0; i < len(users); {
+		userID, minute := users[i].UserID, users[i].Time
+		if i > 0 && userID != users[i-1].UserID {
+			next = 0
+		}
+
+		inMinute := map[int]bool{}
+		for ; i < len(users) && users[i].UserID == userID && users[i].Time.Equal(minute); i++ {
+			inMinute[stepIndex[users[i].Name]] = true
+		}
+
+		for next < len(steps) && inMinute[next] {
+			reached[next]++
+			next++
+		}
+	}
+
+	funnel = model.EventFunnel{Start: startDate, End: endDate, Steps: make([]model.EventFunnelStep, len(steps))}
+	for i, step := range steps {
+		funnel.Steps[i] = model.EventFunnelStep{
+			Name:            step,
+			Users:           reached[i],
+			Conversion:      conversion(reached, i, i-1),
+			TotalConversion: conversion(reached, i, 0),
+		}
+	}
+
+	return funnel, nil
+}
+
+func conversion(reached []uint64, to, from int) float64 {
+	if from < 0 || from == to {
+		return 1
+	}
+	if reached[from] == 0 {
+		return 0
+	}
+
+	return float64(reached[to]) / float64(reached[from])
+}
@@ -0,0 +1,181 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEventFunnel(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("the time zone database isn't available")
+	}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+	occurrence := func(userID, name string, date time.Time) model.EventOccurrence {
+		return model.EventOccurrence{Name: name, Count: 1, Date: date, UserID: userID}
+	}
+	steps := []string{"signup", "login", "checkout"}
+
+	for _, test := range []struct {
+		name        string
+		occurrences []model.EventOccurrence
+		location    *time.Location
+		want        []uint64
+	}{
+		{
+			name: "in order",
+			occurrences: []model.EventOccurrence{
+				occurrence("a", "signup", at(10, 0)),
+				occurrence("a", "login", at(10, 5)),
+				occurrence("a", "checkout", at(10, 10)),
+			},
+			want: []uint64{1, 1, 1},
+		},
+		{
+			name: "out of order steps",
+			occurrences: []model.EventOccurrence{
+				occurrence("a", "checkout", at(10, 0)),
+				occurrence("a", "login", at(10, 5)),
+				occurrence("a", "signup", at(10, 10)),
+				occurrence("b", "login", at(10, 0)),
+				occurrence("b", "signup", at(10, 5)),
+				occurrence("b", "checkout", at(10, 10)),
+			},
+			want: []uint64{2, 0, 0},
+		},
+		{
+			name: "repeated steps",
+			occurrences: []model.EventOccurrence{
+				occurrence("a", "signup", at(10, 0)),
+				occurrence("a", "signup", at(10, 1)),
+				{Name: "login", Count: 3, Date: at(10, 2), UserID: "a"},
+				occurrence("a", "login", at(10, 3)),
+				occurrence("a", "checkout", at(10, 4)),
+				occurrence("a", "checkout", at(10, 5)),
+			},
+			want: []uint64{1, 1, 1},
+		},
+		{
+			name: "several users",
+			occurrences: []model.EventOccurrence{
+				occurrence("a", "signup", at(10, 0)),
+				occurrence("a", "login", at(10, 1)),
+				occurrence("a", "checkout", at(10, 2)),
+				occurrence("b", "signup", at(11, 0)),
+				occurrence("b", "login", at(11, 1)),
+				occurrence("c", "signup", at(12, 0)),
+				occurrence("d", "login", at(12, 0)),
+				occurrence("d", "checkout", at(12, 1)),
+				occurrence("", "signup", at(12, 0)),
+			},
+			want: []uint64{3, 2, 1},
+		},
+		{
+			name: "same minute steps",
+			occurrences: []model.EventOccurrence{
+				occurrence("a", "checkout", at(10, 0).Add(50*time.Second)),
+				occurrence("a", "login", at(10, 0).Add(30*time.Second)),
+				occurrence("a", "signup", at(10, 0).Add(10*time.Second)),
+				occurrence("b", "checkout", at(9, 59)),
+				occurrence("b", "login", at(10, 0)),
+				occurrence("b", "signup", at(10, 0)),
+			},
+			want: []uint64{2, 2, 1},
+		},
+		{
+			// The 2021-03-01 of New York is from 5:00 UTC to 5:00 UTC the next day.
+			name: "range boundaries in a time zone",
+			occurrences: []model.EventOccurrence{
+				occurrence("a", "signup", at(4, 59)),
+				occurrence("a", "login", at(5, 0)),
+				occurrence("b", "signup", at(5, 0)),
+				occurrence("b", "login", at(5, 0).AddDate(0, 0, 1).Add(-time.Minute)),
+				occurrence("b", "checkout", at(5, 0).AddDate(0, 0, 1)),
+				occurrence("c", "signup", at(23, 0)),
+			},
+			location: newYork,
+			want:     []uint64{2, 1, 0},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, backend := range []string{db.StorageSQLite, db.StorageMemory} {
+				storage, err := db.OpenStorage(backend, t.TempDir()+"/events.db", false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if storage.Database != nil {
+					defer storage.Database.Close()
+				}
+				if _, err = storage.MigrateUp(); err != nil {
+					t.Fatal(err)
+				}
+				if err = storage.EventIngestHandler.IngestEvents(test.occurrences); err != nil {
+					t.Fatal(err)
+				}
+
+				location := test.location
+				if location == nil {
+					location = time.UTC
+				}
+				funnel, err := EventService{}.EventFunnel(storage.EventOccurrenceDBHandler, steps, "2021-03-01", "2021-03-01", location)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for i, step := range funnel.Steps {
+					if step.Name != steps[i] || step.Users != test.want[i] {
+						t.Fatalf("%s: steps = %+v, want %v users", backend, funnel.Steps, test.want)
+					}
+					if want := conversion(test.want, i, 0); step.TotalConversion != want {
+						t.Fatalf("%s: total conversion of %s = %v, want %v", backend, step.Name, step.TotalConversion, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEventFunnelConversion(t *testing.T) {
+	storage, err := db.OpenStorage(db.StorageMemory, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var occurrences []model.EventOccurrence
+	for user, reached := range map[string]int{"a": 3, "b": 2, "c": 1, "d": 1, "e": 1} {
+		for i, name := range []string{"signup", "login", "checkout"}[:reached] {
+			occurrences = append(occurrences, model.EventOccurrence{Name: name, Count: 1, Date: time.Date(2021, 3, 1, 10, i, 0, 0, time.UTC), UserID: user})
+		}
+	}
+	if err = storage.EventIngestHandler.IngestEvents(occurrences); err != nil {
+		t.Fatal(err)
+	}
+
+	funnel, err := EventService{}.EventFunnel(storage.EventOccurrenceDBHandler, []string{"signup", "login", "checkout", "refund"}, "2021-03-01", "2021-03-01", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.EventFunnelStep{
+		{Name: "signup", Users: 5, Conversion: 1, TotalConversion: 1},
+		{Name: "login", Users: 2, Conversion: 0.4, TotalConversion: 0.4},
+		{Name: "checkout", Users: 1, Conversion: 0.5, TotalConversion: 0.2},
+		{Name: "refund", Users: 0, Conversion: 0, TotalConversion: 0},
+	}
+	for i := range want {
+		if funnel.Steps[i] != want[i] {
+			t.Fatalf("steps = %+v, want %+v", funnel.Steps, want)
+		}
+	}
+
+	if _, err = (EventService{}).EventFunnel(storage.EventOccurrenceDBHandler, []string{"signup", "login"}, "2021-03-02", "2021-03-01", time.UTC); err != model.ErrInvalidRange {
+		t.Fatalf("a range ending before its start got %v, want %v", err, model.ErrInvalidRange)
+	}
+}
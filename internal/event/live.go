@@ -0,0 +1,91 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"sort"
+	"time"
+)
+
+func (es EventService) LiveHourCount(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name string, hour time.Time) (count uint64, err error) {
+	counts, e := EventOccurrenceDBHandler.GetEventOccurrences(name, hour, hour.Add(time.Hour))
+	if e != nil {
+		return 0, e
+	}
+
+	for _, minuteCount := range counts {
+		count += minuteCount.Count
+	}
+
+	return count, nil
+}
+
+// LiveCounters isn't safe for concurrent use.
+type LiveCounters struct {
+	hour     time.Time
+	counters map[string]*model.LiveCounter
+}
+
+func NewLiveCounters(now time.Time) *LiveCounters {
+	return &LiveCounters{hour: now.UTC().Truncate(time.Hour), counters: map[string]*model.LiveCounter{}}
+}
+
+func (c *LiveCounters) Hour() time.Time {
+	return c.hour
+}
+
+func (c *LiveCounters) Len() int {
+	return len(c.counters)
+}
+
+func (c *LiveCounters) Has(name string) bool {
+	_, ok := c.counters[name]
+	return ok
+}
+
+func (c *LiveCounters) Add(name string, hourCount uint64) {
+	c.counters[name] = &model.LiveCounter{Name: name, HourCount: hourCount}
+}
+
+func (c *LiveCounters) Remove(name string) {
+	delete(c.counters, name)
+}
+
+func (c *LiveCounters) Record(occurrence model.EventOccurrence, now time.Time) {
+	c.roll(now)
+
+	counter, ok := c.counters[occurrence.Name]
+	if !ok {
+		return
+	}
+
+	counter.Delta += occurrence.Count
+	if occurrence.Date.UTC().Truncate(time.Hour).Equal(c.hour) {
+		counter.HourCount += occurrence.Count
+	}
+}
+
+func (c *LiveCounters) Tick(now time.Time) []model.LiveCounter {
+	c.roll(now)
+
+	counters := make([]model.LiveCounter, 0, len(c.counters))
+	for _, counter := range c.counters {
+		counters = append(counters, *counter)
+		counter.Delta = 0
+	}
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Name < counters[j].Name })
+
+	return counters
+}
+
+func (c *LiveCounters) roll(now time.Time) {
+	hour := now.UTC().Truncate(time.Hour)
+	if !hour.After(c.hour) {
+		return
+	}
+
+	c.hour = hour
+	for _, counter := range c.counters {
+		counter.HourCount = 0
+	}
+}
@@ -0,0 +1,133 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+func (es EventService) EventsByProperties(EventPropertyDBHandler db.EventPropertyDBHandler, query model.PropertyQuery, startDate, endDate string, location *time.Location) (events []model.Event, err error) {
+	// A local day overlaps the UTC days around it, which are read and then filtered out.
+	utcStartDate, utcEndDate := startDate, endDate
+	if location != time.UTC {
+		utcStartDate, utcEndDate = shiftDate(startDate, -1), shiftDate(endDate, 1)
+	}
+
+	counts, e := EventPropertyDBHandler.GetEventProperties("", utcStartDate, utcEndDate)
+	if e != nil {
+		return []model.Event{}, nil
+	}
+
+	byKey := map[string]*model.Event{}
+	var keys []string
+	for _, count := range counts {
+		if !matchesProperties(count.Properties, query.Where) {
+			continue
+		}
+
+		date, _, _ := localDateHour(count.Date, count.Hour, location)
+		if (startDate != "" && date < startDate) || (endDate != "" && date > endDate) {
+			continue
+		}
+
+		group, groupKey := groupProperties(count.Properties, query.GroupBy)
+		key := count.Name + "\x00" + date + "\x00" + groupKey
+
+		event, ok := byKey[key]
+		if !ok {
+			event = &model.Event{Name: count.Name, Date: date, Properties: group}
+			byKey[key] = event
+			keys = append(keys, key)
+		}
+		event.Count += count.Count
+	}
+
+	sort.Strings(keys)
+	events = []model.Event{}
+	for _, key := range keys {
+		events = append(events, *byKey[key])
+	}
+
+	return events, nil
+}
+
+func (es EventService) EventFrequenciesByProperties(EventPropertyDBHandler db.EventPropertyDBHandler, name string, query model.PropertyQuery, location *time.Location) (eventFreqs []model.EventFreq, err error) {
+	counts, e := EventPropertyDBHandler.GetEventProperties(name, "", "")
+	if e != nil {
+		if name != "" {
+			return nil, model.ErrEventNotFound
+		}
+		return []model.EventFreq{}, nil
+	}
+	if name != "" && len(counts) == 0 {
+		return nil, model.ErrEventNotFound
+	}
+
+	byKey := map[string]*model.EventFreq{}
+	var keys []string
+	for _, count := range counts {
+		if !matchesProperties(count.Properties, query.Where) {
+			continue
+		}
+
+		group, groupKey := groupProperties(count.Properties, query.GroupBy)
+		key := count.Name + "\x00" + groupKey
+
+		eventFreq, ok := byKey[key]
+		if !ok {
+			eventFreq = &model.EventFreq{Name: count.Name, Properties: group}
+			byKey[key] = eventFreq
+			keys = append(keys, key)
+		}
+		_, hour, weekday := localDateHour(count.Date, count.Hour, location)
+		eventFreq.TotalCount += count.Count
+		eventFreq.HourCount[hour] += count.Count
+		eventFreq.WeekdayCount[weekday] += count.Count
+		eventFreq.WeekdayHourCount[weekday][hour] += count.Count
+	}
+
+	sort.Strings(keys)
+	eventFreqs = []model.EventFreq{}
+	for _, key := range keys {
+		eventFreqs = append(eventFreqs, *byKey[key])
+	}
+
+	return eventFreqs, nil
+}
+
+func matchesProperties(properties, where map[string]string) bool {
+	for key, value := range where {
+		if properties[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Occurrences without one of the group by properties are grouped under its empty value.
+func groupProperties(properties map[string]string, groupBy []string) (group map[string]string, groupKey string) {
+	if len(groupBy) == 0 {
+		return nil, ""
+	}
+
+	group = map[string]string{}
+	values := make([]string, len(groupBy))
+	for i, key := range groupBy {
+		group[key] = properties[key]
+		values[i] = properties[key]
+	}
+
+	return group, strings.Join(values, "\x00")
+}
+
+func shiftDate(date string, days int) string {
+	parsedDate, e := time.Parse("2006-01-02", date)
+	if e != nil {
+		return date
+	}
+
+	return parsedDate.AddDate(0, 0, days).Format("2006-01-02")
+}
@@ -0,0 +1,115 @@
+package event
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"fmt"
+	"time"
+)
+
+// A retention of N days keeps the daily rows of the current UTC day and of the N-1 days before it.
+
+func (es EventService) RetentionSettings(EventRetentionDBHandler db.EventRetentionDBHandler) (settings model.RetentionSettings, err error) {
+	retentions, e := EventRetentionDBHandler.GetRetentions()
+	if e != nil {
+		return model.RetentionSettings{}, e
+	}
+
+	settings.Events = []model.EventRetention{}
+	for _, retention := range retentions {
+		if retention.Name == "" {
+			settings.Days = retention.Days
+			continue
+		}
+		settings.Events = append(settings.Events, retention)
+	}
+
+	return settings, nil
+}
+
+func (es EventService) SetRetention(EventRetentionDBHandler db.EventRetentionDBHandler, name string, days uint64) (err error) {
+	return EventRetentionDBHandler.SetRetention(name, days)
+}
+
+func (es EventService) DeleteRetention(EventRetentionDBHandler db.EventRetentionDBHandler, name string) (err error) {
+	return EventRetentionDBHandler.DeleteRetention(name)
+}
+
+// PruneExpiredEvents falls back to the global retention of the project, and then to the one of the default project.
+func (es EventService) PruneExpiredEvents(EventDBFreqHandler db.EventFreqDBHandler, EventRetentionDBHandler db.EventRetentionDBHandler, now time.Time, batchSize int) (pruned int, err error) {
+	settings, e := es.RetentionSettings(EventRetentionDBHandler)
+	if e != nil {
+		return 0, e
+	}
+	if settings.Days == 0 {
+		defaults, e := es.RetentionSettings(EventRetentionDBHandler.ForProject(db.DefaultProject))
+		if e != nil {
+			return 0, e
+		}
+		settings.Days = defaults.Days
+	}
+
+	retentionByName := map[string]uint64{}
+	for _, retention := range settings.Events {
+		retentionByName[retention.Name] = retention.Days
+	}
+
+	events, e := EventDBFreqHandler.GetEventsHistory()
+	if e != nil {
+		return 0, e
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, event := range events {
+		days, ok := retentionByName[event.Name]
+		if !ok {
+			days = settings.Days
+		}
+		if days == 0 {
+			continue
+		}
+
+		beforeDate := today.AddDate(0, 0, 1-int(days)).Format("2006-01-02")
+		for {
+			batchPruned, e := EventRetentionDBHandler.PruneEvents(event.Name, beforeDate, batchSize)
+			if e != nil {
+				return pruned, errors.New(fmt.Sprintf(model.ErrPruneEvent.Error(), event.Name, e.Error()))
+			}
+
+			pruned += batchPruned
+			if batchPruned < batchSize {
+				break
+			}
+		}
+	}
+
+	return pruned, nil
+}
+
+func (es EventService) RunJanitor(EventDBFreqHandler db.EventFreqDBHandler, EventRetentionDBHandler db.EventRetentionDBHandler, interval time.Duration, batchSize int, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		projects, e := EventRetentionDBHandler.GetProjects()
+		if e != nil {
+			println(fmt.Sprintf("Error reading the projects: %s", e.Error()))
+		}
+
+		for _, project := range projects {
+			pruned, e := es.PruneExpiredEvents(EventDBFreqHandler.ForProject(project), EventRetentionDBHandler.ForProject(project), time.Now().UTC(), batchSize)
+			if e != nil {
+				println(fmt.Sprintf("Error pruning expired events of project %s: %s", project, e.Error()))
+			} else if pruned > 0 {
+				println(fmt.Sprintf("Pruned %d expired daily rows of project %s", pruned, project))
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
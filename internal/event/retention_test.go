@@ -0,0 +1,49 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"testing"
+	"time"
+)
+
+func TestRunJanitorAppliesDefaultRetention(t *testing.T) {
+	storage, err := db.OpenStorage(db.StorageMemory, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	for _, project := range []string{"acme", "globex"} {
+		err = storage.EventIngestHandler.ForProject(project).IngestEvents([]model.EventOccurrence{
+			{Name: "login", Count: 2, Date: now.AddDate(0, 0, -30)},
+			{Name: "login", Count: 1, Date: now},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	es := EventService{}
+	if err = es.SetRetention(storage.EventRetentionDBHandler, "", 7); err != nil {
+		t.Fatal(err)
+	}
+	if err = es.SetRetention(storage.EventRetentionDBHandler.ForProject("globex"), "", 60); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	es.RunJanitor(storage.EventFreqDBHandler, storage.EventRetentionDBHandler, time.Hour, 100, stop)
+
+	// acme has no retention, so the one of the default project applies, while globex keeps its own.
+	for project, want := range map[string]uint64{"acme": 1, "globex": 3} {
+		eventFreq, err := storage.EventFreqDBHandler.ForProject(project).GetEventByName("login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eventFreq.TotalCount != want {
+			t.Fatalf("%s has %d occurrences left, want %d", project, eventFreq.TotalCount, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package event
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// rollupPeriods are the periods that add up into each interval, from the coarsest. Weeks don't fit into months.
+var rollupPeriods = map[string][]string{
+	IntervalDay:   {db.RollupDay},
+	IntervalWeek:  {db.RollupWeek, db.RollupDay},
+	IntervalMonth: {db.RollupMonth, db.RollupDay},
+	IntervalYear:  {db.RollupYear, db.RollupMonth, db.RollupDay},
+}
+
+type rollupRun struct {
+	period     string
+	start, end time.Time
+}
+
+// EventsByInterval reads the whole periods from the coarsest rollup that fits them, and the days left at the edges.
+func (es EventService) EventsByInterval(EventRollupDBHandler db.EventRollupDBHandler, name, startDate, endDate, interval string) (events []model.Event, err error) {
+	periods, ok := rollupPeriods[interval]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(model.ErrInvalidInterval.Error(), interval))
+	}
+
+	var runs []rollupRun
+	if startDate == "" {
+		runs = []rollupRun{{period: periods[0]}}
+	} else {
+		start, e := time.Parse("2006-01-02", startDate)
+		if e != nil {
+			return nil, e
+		}
+		end, e := time.Parse("2006-01-02", endDate)
+		if e != nil {
+			return nil, e
+		}
+		if end.Before(start) {
+			return nil, model.ErrInvalidRange
+		}
+
+		runs = coverRange(start, end.AddDate(0, 0, 1), periods)
+	}
+
+	byKey := map[string]*model.Event{}
+	var keys []string
+	for _, run := range runs {
+		var firstDate, lastDate string
+		if !run.start.IsZero() {
+			firstDate, lastDate = run.start.Format("2006-01-02"), run.end.AddDate(0, 0, -1).Format("2006-01-02")
+		}
+
+		counts, e := EventRollupDBHandler.GetEventRollups(name, run.period, firstDate, lastDate)
+		if e != nil {
+			return nil, e
+		}
+
+		for _, count := range counts {
+			periodStart, e := time.Parse("2006-01-02", count.Date)
+			if e != nil {
+				continue
+			}
+
+			date := TruncateTime(periodStart, interval).Format("2006-01-02")
+			key := date + "\x00" + count.Name
+			event, ok := byKey[key]
+			if !ok {
+				event = &model.Event{Name: count.Name, Date: date}
+				byKey[key] = event
+				keys = append(keys, key)
+			}
+			event.Count += count.Count
+		}
+	}
+
+	sort.Strings(keys)
+	events = []model.Event{}
+	for _, key := range keys {
+		events = append(events, *byKey[key])
+	}
+
+	return events, nil
+}
+
+func coverRange(start, end time.Time, periods []string) (runs []rollupRun) {
+	period := periods[0]
+	if len(periods) == 1 {
+		return []rollupRun{{period: period, start: start, end: end}}
+	}
+
+	first := TruncateTime(start, period)
+	if first.Before(start) {
+		first = NextBucket(first, period)
+	}
+	last := TruncateTime(end, period)
+	if !first.Before(last) {
+		return coverRange(start, end, periods[1:])
+	}
+
+	if start.Before(first) {
+		runs = append(runs, coverRange(start, first, periods[1:])...)
+	}
+	runs = append(runs, rollupRun{period: period, start: first, end: last})
+	if last.Before(end) {
+		runs = append(runs, coverRange(last, end, periods[1:])...)
+	}
+
+	return runs
+}
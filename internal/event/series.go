@@ -0,0 +1,126 @@
+package event
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"fmt"
+	"time"
+)
+
+const (
+	IntervalMinute = "minute"
+	IntervalHour   = "hour"
+	IntervalDay    = "day"
+	IntervalWeek   = "week"
+	IntervalMonth  = "month"
+	IntervalYear   = "year"
+)
+
+const maxSeriesPoints = 10000
+
+// EventSeries reads the days and longer intervals in UTC from the rollups, and the others from the counts by minute.
+func (es EventService) EventSeries(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, EventRollupDBHandler db.EventRollupDBHandler, name string, start, end time.Time, interval string) (series model.EventSeries, err error) {
+	if !ValidInterval(interval) {
+		return model.EventSeries{}, errors.New(fmt.Sprintf(model.ErrInvalidInterval.Error(), interval))
+	}
+	if end.Before(start) {
+		return model.EventSeries{}, model.ErrInvalidRange
+	}
+
+	location := start.Location()
+	firstBucket := TruncateTime(start, interval)
+	lastBucket := TruncateTime(end.In(location), interval)
+	endBucket := NextBucket(lastBucket, interval)
+
+	var buckets []time.Time
+	for bucket := firstBucket; bucket.Before(endBucket); bucket = NextBucket(bucket, interval) {
+		if len(buckets) == maxSeriesPoints {
+			return model.EventSeries{}, errors.New(fmt.Sprintf(model.ErrTooManyPoints.Error(), maxSeriesPoints))
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	countByBucket := map[int64]uint64{}
+	if periods, ok := rollupPeriods[interval]; ok && location == time.UTC {
+		counts, e := EventRollupDBHandler.GetEventRollups(name, periods[0], firstBucket.Format("2006-01-02"), lastBucket.Format("2006-01-02"))
+		if e != nil {
+			return model.EventSeries{}, e
+		}
+
+		for _, count := range counts {
+			bucket, e := time.Parse("2006-01-02", count.Date)
+			if e != nil {
+				continue
+			}
+			countByBucket[bucket.Unix()] += count.Count
+		}
+	} else {
+		counts, e := EventOccurrenceDBHandler.GetEventOccurrences(name, firstBucket, endBucket)
+		if e != nil {
+			return model.EventSeries{}, e
+		}
+
+		for _, count := range counts {
+			countByBucket[TruncateTime(count.Time.In(location), interval).Unix()] += count.Count
+		}
+	}
+
+	series = model.EventSeries{
+		Name:     name,
+		Interval: interval,
+		Start:    firstBucket.Format(time.RFC3339),
+		End:      endBucket.Format(time.RFC3339),
+		Points:   make([]model.EventSeriesPoint, len(buckets)),
+	}
+	for i, bucket := range buckets {
+		series.Points[i] = model.EventSeriesPoint{Time: bucket.Format(time.RFC3339), Count: countByBucket[bucket.Unix()]}
+	}
+
+	return series, nil
+}
+
+func ValidInterval(interval string) bool {
+	switch interval {
+	case IntervalMinute, IntervalHour, IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// TruncateTime starts the weeks on Monday, and keeps the offset of t for the minutes and hours, so that
+// the hour repeated when the clocks are set back is two intervals.
+func TruncateTime(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalMinute:
+		return t.Add(-time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case IntervalHour:
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case IntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case IntervalYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+func NextBucket(bucket time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalMinute:
+		return bucket.Add(time.Minute)
+	case IntervalHour:
+		return bucket.Add(time.Hour)
+	case IntervalWeek:
+		return bucket.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return bucket.AddDate(0, 1, 0)
+	case IntervalYear:
+		return bucket.AddDate(1, 0, 0)
+	default:
+		return bucket.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"testing"
+	"time"
+)
+
+func TestEventSeriesFallBack(t *testing.T) {
+	storage, err := db.OpenStorage(db.StorageMemory, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("the time zone database isn't available")
+	}
+
+	// On 2021-11-07 the clocks go back from 2:00 EDT to 1:00 EST, so 1:30 happens at 5:30 and 6:30 UTC.
+	occurrences := []model.EventOccurrence{
+		{Name: "login", Count: 2, Date: time.Date(2021, 11, 7, 5, 30, 0, 0, time.UTC)},
+		{Name: "login", Count: 3, Date: time.Date(2021, 11, 7, 6, 30, 0, 0, time.UTC)},
+		{Name: "login", Count: 4, Date: time.Date(2021, 11, 7, 7, 30, 0, 0, time.UTC)},
+	}
+	if err = storage.EventIngestHandler.IngestEvents(occurrences); err != nil {
+		t.Fatal(err)
+	}
+
+	es := EventService{}
+	start := time.Date(2021, 11, 7, 0, 0, 0, 0, location)
+	end := time.Date(2021, 11, 7, 2, 59, 0, 0, location)
+	series, err := es.EventSeries(storage.EventOccurrenceDBHandler, storage.EventRollupDBHandler, "login", start, end, IntervalHour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.EventSeriesPoint{
+		{Time: "2021-11-07T00:00:00-04:00", Count: 0},
+		{Time: "2021-11-07T01:00:00-04:00", Count: 2},
+		{Time: "2021-11-07T01:00:00-05:00", Count: 3},
+		{Time: "2021-11-07T02:00:00-05:00", Count: 4},
+	}
+	if len(series.Points) != len(want) {
+		t.Fatalf("points = %v, want %v", series.Points, want)
+	}
+	for i := range want {
+		if series.Points[i] != want[i] {
+			t.Fatalf("points = %v, want %v", series.Points, want)
+		}
+	}
+
+	events, err := es.EventsInLocation(storage.EventOccurrenceDBHandler, "login", "2021-11-07", "2021-11-07", location)
+	if err != nil || len(events) != 1 || events[0].Count != 9 {
+		t.Fatalf("events of 2021-11-07 in New York = %v, %v, want 9 occurrences", events, err)
+	}
+}
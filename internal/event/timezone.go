@@ -0,0 +1,106 @@
+package event
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/model"
+	"sort"
+	"time"
+)
+
+// The daily and hourly tables are bucketed in UTC, so the reads in other locations are computed
+// from the counts by minute of eventOccurrenceDB instead.
+
+func (es EventService) EventsInLocation(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name, startDate, endDate string, location *time.Location) (events []model.Event, err error) {
+	var start, end time.Time
+	if startDate != "" {
+		start, err = time.ParseInLocation("2006-01-02", startDate, location)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if endDate != "" {
+		end, err = time.ParseInLocation("2006-01-02", endDate, location)
+		if err != nil {
+			return nil, err
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+
+	counts, e := EventOccurrenceDBHandler.GetEventOccurrences(name, start, end)
+	if e != nil {
+		return nil, e
+	}
+
+	byKey := map[string]*model.Event{}
+	var keys []string
+	for _, count := range counts {
+		date := count.Time.In(location).Format("2006-01-02")
+		key := count.Name + "\x00" + date
+
+		event, ok := byKey[key]
+		if !ok {
+			event = &model.Event{Name: count.Name, Date: date}
+			byKey[key] = event
+			keys = append(keys, key)
+		}
+		event.Count += count.Count
+	}
+
+	if name != "" && len(keys) == 0 {
+		return nil, model.ErrEventNotFound
+	}
+
+	sort.Strings(keys)
+	events = []model.Event{}
+	for _, key := range keys {
+		events = append(events, *byKey[key])
+	}
+
+	return events, nil
+}
+
+func (es EventService) EventFrequenciesInLocation(EventOccurrenceDBHandler db.EventOccurrenceDBHandler, name string, location *time.Location) (eventFreqs []model.EventFreq, err error) {
+	counts, e := EventOccurrenceDBHandler.GetEventOccurrences(name, time.Time{}, time.Time{})
+	if e != nil {
+		return nil, e
+	}
+
+	byName := map[string]*model.EventFreq{}
+	var names []string
+	for _, count := range counts {
+		eventFreq, ok := byName[count.Name]
+		if !ok {
+			eventFreq = &model.EventFreq{Name: count.Name}
+			byName[count.Name] = eventFreq
+			names = append(names, count.Name)
+		}
+		local := count.Time.In(location)
+		eventFreq.TotalCount += count.Count
+		eventFreq.HourCount[local.Hour()] += count.Count
+		eventFreq.WeekdayCount[local.Weekday()] += count.Count
+		eventFreq.WeekdayHourCount[local.Weekday()][local.Hour()] += count.Count
+	}
+
+	if name != "" && len(names) == 0 {
+		return nil, model.ErrEventNotFound
+	}
+
+	sort.Strings(names)
+	eventFreqs = []model.EventFreq{}
+	for _, n := range names {
+		eventFreqs = append(eventFreqs, *byName[n])
+	}
+
+	return eventFreqs, nil
+}
+
+// In the locations whose offset isn't a whole number of hours, a UTC hour goes to the local hour it starts in.
+func localDateHour(date string, hour uint64, location *time.Location) (string, uint64, time.Weekday) {
+	day, e := time.Parse("2006-01-02", date)
+	if e != nil {
+		return date, hour, time.Sunday
+	}
+
+	local := day.Add(time.Duration(hour) * time.Hour).In(location)
+	return local.Format("2006-01-02"), uint64(local.Hour()), local.Weekday()
+}
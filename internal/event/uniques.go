@@ -0,0 +1,140 @@
+package event
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/hll"
+	"eventTracker/internal/model"
+	"fmt"
+	"time"
+)
+
+func (es EventService) EventUniques(EventUniqueDBHandler db.EventUniqueDBHandler, name, startDate, endDate, interval string) (uniques model.EventUniques, err error) {
+	if _, ok := rollupPeriods[interval]; !ok {
+		return model.EventUniques{}, errors.New(fmt.Sprintf(model.ErrInvalidInterval.Error(), interval))
+	}
+
+	start, e := time.Parse("2006-01-02", startDate)
+	if e != nil {
+		return model.EventUniques{}, e
+	}
+	end, e := time.Parse("2006-01-02", endDate)
+	if e != nil {
+		return model.EventUniques{}, e
+	}
+	if end.Before(start) {
+		return model.EventUniques{}, model.ErrInvalidRange
+	}
+
+	var dates []string
+	endBucket := NextBucket(TruncateTime(end, interval), interval)
+	for bucket := TruncateTime(start, interval); bucket.Before(endBucket); bucket = NextBucket(bucket, interval) {
+		if len(dates) == maxSeriesPoints {
+			return model.EventUniques{}, errors.New(fmt.Sprintf(model.ErrTooManyPoints.Error(), maxSeriesPoints))
+		}
+		dates = append(dates, bucket.Format("2006-01-02"))
+	}
+
+	registers, e := EventUniqueDBHandler.GetEventUniques(name, startDate, endDate)
+	if e != nil {
+		return model.EventUniques{}, e
+	}
+
+	total := hll.New()
+	sketches := map[string]*hll.Sketch{}
+	for _, register := range registers {
+		date, e := bucketDate(register.Date, interval)
+		if e != nil {
+			continue
+		}
+
+		sketch, ok := sketches[date]
+		if !ok {
+			sketch = hll.New()
+			sketches[date] = sketch
+		}
+		sketch.Set(register.Register, register.Rank)
+		total.Set(register.Register, register.Rank)
+	}
+
+	uniques = model.EventUniques{
+		Name:     name,
+		Interval: interval,
+		Start:    startDate,
+		End:      endDate,
+		Uniques:  total.Estimate(),
+		Points:   make([]model.EventUniquesPoint, len(dates)),
+	}
+	for i, date := range dates {
+		uniques.Points[i] = model.EventUniquesPoint{Date: date}
+		if sketch, ok := sketches[date]; ok {
+			uniques.Points[i].Uniques = sketch.Estimate()
+		}
+	}
+
+	return uniques, nil
+}
+
+// The Date of the events is the first UTC day of their interval.
+func (es EventService) AddEventUniques(EventUniqueDBHandler db.EventUniqueDBHandler, events []model.Event, interval string) (err error) {
+	if len(events) == 0 {
+		return nil
+	}
+	if _, ok := rollupPeriods[interval]; !ok {
+		return errors.New(fmt.Sprintf(model.ErrInvalidInterval.Error(), interval))
+	}
+
+	firstDate, lastDate := events[0].Date, events[0].Date
+	for _, event := range events {
+		if event.Date < firstDate {
+			firstDate = event.Date
+		}
+		if event.Date > lastDate {
+			lastDate = event.Date
+		}
+	}
+	last, e := time.Parse("2006-01-02", lastDate)
+	if e != nil {
+		return e
+	}
+
+	registers, e := EventUniqueDBHandler.GetEventUniques("", firstDate, NextBucket(last, interval).AddDate(0, 0, -1).Format("2006-01-02"))
+	if e != nil {
+		return e
+	}
+
+	sketches := map[string]*hll.Sketch{}
+	for _, register := range registers {
+		date, e := bucketDate(register.Date, interval)
+		if e != nil {
+			continue
+		}
+
+		key := register.Name + "\x00" + date
+		sketch, ok := sketches[key]
+		if !ok {
+			sketch = hll.New()
+			sketches[key] = sketch
+		}
+		sketch.Set(register.Register, register.Rank)
+	}
+
+	for i := range events {
+		var count uint64
+		if sketch, ok := sketches[events[i].Name+"\x00"+events[i].Date]; ok {
+			count = sketch.Estimate()
+		}
+		events[i].Uniques = &count
+	}
+
+	return nil
+}
+
+func bucketDate(date, interval string) (string, error) {
+	day, e := time.Parse("2006-01-02", date)
+	if e != nil {
+		return "", e
+	}
+
+	return TruncateTime(day, interval).Format("2006-01-02"), nil
+}
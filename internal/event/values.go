@@ -0,0 +1,124 @@
+package event
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/ddsketch"
+	"eventTracker/internal/model"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+var DefaultPercentiles = []float64{50, 90, 95, 99}
+
+type valueInterval struct {
+	count    uint64
+	total    float64
+	min, max float64
+	sketch   *ddsketch.Sketch
+}
+
+func newValueInterval() *valueInterval {
+	return &valueInterval{min: math.Inf(1), max: math.Inf(-1), sketch: ddsketch.New()}
+}
+
+func (v *valueInterval) addStats(stats model.EventValueStats) {
+	v.count += stats.Count
+	v.total += stats.Total
+	v.min = math.Min(v.min, stats.Min)
+	v.max = math.Max(v.max, stats.Max)
+}
+
+// The percentiles are kept within the minimum and the maximum, which are exact.
+func (v *valueInterval) summary(percentiles []float64) model.EventValueSummary {
+	summary := model.EventValueSummary{Count: v.count, Sum: v.total}
+	if v.count == 0 {
+		return summary
+	}
+
+	minValue, maxValue, mean := v.min, v.max, v.total/float64(v.count)
+	summary.Min, summary.Max, summary.Mean = &minValue, &maxValue, &mean
+	summary.Percentiles = map[string]float64{}
+	for _, p := range percentiles {
+		value := math.Max(minValue, math.Min(maxValue, v.sketch.Quantile(p/100)))
+		if p == 0 {
+			value = minValue
+		} else if p == 100 {
+			value = maxValue
+		}
+		summary.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = value
+	}
+
+	return summary
+}
+
+// The values are kept by UTC hour, which counts in the interval it starts in.
+func (es EventService) EventValues(EventValueDBHandler db.EventValueDBHandler, name string, start, end time.Time, interval string, percentiles []float64) (values model.EventValues, err error) {
+	if interval == IntervalMinute || !ValidInterval(interval) {
+		return model.EventValues{}, errors.New(fmt.Sprintf(model.ErrInvalidValueInterval.Error(), interval))
+	}
+	if end.Before(start) {
+		return model.EventValues{}, model.ErrInvalidRange
+	}
+
+	location := start.Location()
+	firstBucket := TruncateTime(start, interval)
+	endBucket := NextBucket(TruncateTime(end.In(location), interval), interval)
+
+	var buckets []time.Time
+	for bucket := firstBucket; bucket.Before(endBucket); bucket = NextBucket(bucket, interval) {
+		if len(buckets) == maxSeriesPoints {
+			return model.EventValues{}, errors.New(fmt.Sprintf(model.ErrTooManyPoints.Error(), maxSeriesPoints))
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	stats, e := EventValueDBHandler.GetEventValues(name, firstBucket, endBucket)
+	if e != nil {
+		return model.EventValues{}, e
+	}
+	sketchBuckets, e := EventValueDBHandler.GetEventValueBuckets(name, firstBucket, endBucket)
+	if e != nil {
+		return model.EventValues{}, e
+	}
+
+	total := newValueInterval()
+	intervals := map[int64]*valueInterval{}
+	intervalOf := func(hour time.Time) *valueInterval {
+		bucket := TruncateTime(hour.In(location), interval).Unix()
+		v, ok := intervals[bucket]
+		if !ok {
+			v = newValueInterval()
+			intervals[bucket] = v
+		}
+		return v
+	}
+	for _, hourStats := range stats {
+		intervalOf(hourStats.Time).addStats(hourStats)
+		total.addStats(hourStats)
+	}
+	for _, sketchBucket := range sketchBuckets {
+		intervalOf(sketchBucket.Time).sketch.AddKey(sketchBucket.Bucket, sketchBucket.Count)
+		total.sketch.AddKey(sketchBucket.Bucket, sketchBucket.Count)
+	}
+
+	values = model.EventValues{
+		Name:     name,
+		Interval: interval,
+		Start:    firstBucket.Format(time.RFC3339),
+		End:      endBucket.Format(time.RFC3339),
+		Summary:  total.summary(percentiles),
+		Points:   make([]model.EventValuesPoint, len(buckets)),
+	}
+	for i, bucket := range buckets {
+		v, ok := intervals[bucket.Unix()]
+		if !ok {
+			v = newValueInterval()
+		}
+		values.Points[i] = model.EventValuesPoint{Time: bucket.Format(time.RFC3339), EventValueSummary: v.summary(percentiles)}
+	}
+
+	return values, nil
+}
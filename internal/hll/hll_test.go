@@ -0,0 +1,72 @@
+package hll
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestEstimateAccuracy(t *testing.T) {
+	// The standard error is 1.04/sqrt(Registers), about 1.6%: the root mean square of the relative errors
+	// of independent sets is close to it, and no set is off by more than about 3 standard errors.
+	for _, n := range []int{1000, 10000, 100000, 1000000} {
+		trials := 20
+		if n == 1000000 {
+			trials = 5
+		}
+
+		var squares float64
+		for trial := 0; trial < trials; trial++ {
+			sketch := New()
+			for i := 0; i < n; i++ {
+				sketch.Add(strconv.Itoa(trial) + ":user-" + strconv.Itoa(i))
+			}
+
+			relativeError := (float64(sketch.Estimate()) - float64(n)) / float64(n)
+			if math.Abs(relativeError) > 0.05 {
+				t.Fatalf("%d distinct values are estimated at %d in trial %d", n, sketch.Estimate(), trial)
+			}
+			squares += relativeError * relativeError
+		}
+
+		if rms := math.Sqrt(squares / float64(trials)); rms > 0.02 {
+			t.Fatalf("the estimates of %d distinct values are off by %.2f%% on average, want about 1.6%%", n, 100*rms)
+		}
+	}
+}
+
+func TestEstimateSmallCardinalities(t *testing.T) {
+	sketch := New()
+	if estimate := sketch.Estimate(); estimate != 0 {
+		t.Fatalf("an empty sketch is estimated at %d", estimate)
+	}
+
+	for i := 1; i <= 100; i++ {
+		sketch.Add("user-" + strconv.Itoa(i))
+		sketch.Add("user-" + strconv.Itoa(i))
+		if estimate := sketch.Estimate(); math.Abs(float64(estimate)-float64(i)) > math.Max(1, 0.02*float64(i)) {
+			t.Fatalf("%d distinct values added twice are estimated at %d", i, estimate)
+		}
+	}
+}
+
+func TestMergeIsTheSketchOfTheUnion(t *testing.T) {
+	a, b, union := New(), New(), New()
+	for i := 0; i < 60000; i++ {
+		a.Add("user-" + strconv.Itoa(i))
+	}
+	for i := 40000; i < 100000; i++ {
+		b.Add("user-" + strconv.Itoa(i))
+	}
+	for i := 0; i < 100000; i++ {
+		union.Add("user-" + strconv.Itoa(i))
+	}
+
+	a.Merge(b)
+	if *a != *union {
+		t.Fatal("the merged sketch isn't the sketch of the union")
+	}
+	if estimate := a.Estimate(); math.Abs(float64(estimate)-100000) > 5000 {
+		t.Fatalf("the union of 100000 distinct values is estimated at %d", estimate)
+	}
+}
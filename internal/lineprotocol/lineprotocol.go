@@ -0,0 +1,174 @@
+package lineprotocol
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The values of Fields are float64, int64, uint64, string or bool.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]interface{}
+	Timestamp   time.Time
+}
+
+func Precision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	}
+
+	return 0, fmt.Errorf("invalid precision %q, must be ns, us, ms or s", precision)
+}
+
+// The empty lines and the comments must be skipped before ParseLine.
+func ParseLine(line string, precision time.Duration) (point Point, err error) {
+	var i int
+	point.Measurement, i = readUntil(line, 0, ", ")
+	if point.Measurement == "" {
+		return Point{}, errors.New("missing measurement")
+	}
+
+	for i < len(line) && line[i] == ',' {
+		var key, value string
+		key, i = readUntil(line, i+1, "=, ")
+		if i >= len(line) || line[i] != '=' || key == "" {
+			return Point{}, fmt.Errorf("invalid tag %q", key)
+		}
+
+		value, i = readUntil(line, i+1, "=, ")
+		if value == "" || (i < len(line) && line[i] == '=') {
+			return Point{}, fmt.Errorf("invalid value of tag %q", key)
+		}
+
+		if point.Tags == nil {
+			point.Tags = map[string]string{}
+		}
+		point.Tags[key] = value
+	}
+
+	i = skipSpaces(line, i)
+	if i >= len(line) {
+		return Point{}, errors.New("missing fields")
+	}
+
+	point.Fields = map[string]interface{}{}
+	for {
+		var key string
+		key, i = readUntil(line, i, "=, ")
+		if i >= len(line) || line[i] != '=' || key == "" {
+			return Point{}, fmt.Errorf("invalid field %q", key)
+		}
+
+		var value interface{}
+		value, i, err = readFieldValue(line, i+1)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid value of field %q: %s", key, err.Error())
+		}
+		point.Fields[key] = value
+
+		if i >= len(line) || line[i] != ',' {
+			break
+		}
+		i++
+	}
+
+	i = skipSpaces(line, i)
+	if i < len(line) {
+		timestamp, e := strconv.ParseInt(line[i:], 10, 64)
+		if e != nil {
+			return Point{}, fmt.Errorf("invalid timestamp %q", line[i:])
+		}
+		if timestamp > math.MaxInt64/int64(precision) || timestamp < math.MinInt64/int64(precision) {
+			return Point{}, fmt.Errorf("timestamp %d out of range", timestamp)
+		}
+
+		point.Timestamp = time.Unix(0, timestamp*int64(precision)).UTC()
+	}
+
+	return point, nil
+}
+
+func readUntil(line string, i int, stops string) (string, int) {
+	var text strings.Builder
+	for ; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && (line[i+1] == '\\' || strings.IndexByte(stops, line[i+1]) >= 0) {
+			i++
+			text.WriteByte(line[i])
+			continue
+		}
+		if strings.IndexByte(stops, line[i]) >= 0 {
+			break
+		}
+		text.WriteByte(line[i])
+	}
+
+	return text.String(), i
+}
+
+func skipSpaces(line string, i int) int {
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+
+	return i
+}
+
+func readFieldValue(line string, i int) (value interface{}, next int, err error) {
+	if i < len(line) && line[i] == '"' {
+		var text strings.Builder
+		for i++; i < len(line); i++ {
+			switch {
+			case line[i] == '\\' && i+1 < len(line) && (line[i+1] == '"' || line[i+1] == '\\'):
+				i++
+				text.WriteByte(line[i])
+			case line[i] == '"':
+				return text.String(), i + 1, nil
+			default:
+				text.WriteByte(line[i])
+			}
+		}
+
+		return nil, i, errors.New("unterminated string")
+	}
+
+	raw, next := readUntil(line, i, ", ")
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return true, next, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, next, nil
+	case "":
+		return nil, next, errors.New("missing value")
+	}
+
+	switch raw[len(raw)-1] {
+	case 'i':
+		value, err = strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+	case 'u':
+		value, err = strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+	default:
+		var float float64
+		float, err = strconv.ParseFloat(raw, 64)
+		if err == nil && (math.IsNaN(float) || math.IsInf(float, 0)) {
+			err = errors.New("not a finite number")
+		}
+		value = float
+	}
+	if err != nil {
+		return nil, next, fmt.Errorf("invalid number %q", raw)
+	}
+
+	return value, next, nil
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "eventtracker_http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests by route.",
+		Buckets: DefaultBuckets,
+	}, []string{"method", "route", "code"})
+	QueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "eventtracker_db_query_duration_seconds",
+		Help:    "Duration of the calls to the storage handlers.",
+		Buckets: DefaultBuckets,
+	}, []string{"backend", "handler", "operation"})
+)
+
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(RequestDuration, QueryDuration)
+}
@@ -8,10 +8,45 @@ var (
 	ErrInsertEventFreqDB      = errors.New("error inserting new event in event freq db: %s")
 	ErrUpdateEventDB          = errors.New("error updating new event in event db: %s")
 	ErrUpdateEventFreqDB      = errors.New("error updating new event in event freq db: %s")
+	ErrIngestEvent            = errors.New("error recording new event occurrences: %s")
 	ErrDeleteEventDB          = errors.New("error deleting new event in event db: %s")
 	ErrDeleteEventFreqDB      = errors.New("error deleting new event in event freq db: %s")
+	ErrDeleteEventPropertyDB  = errors.New("error deleting event in event property db: %s")
 	ErrParseHour              = errors.New("error parsing hour into int")
 	ErrDoesntExistEventDB     = errors.New("error trying to delete non existing event %s")
 	ErrDoesntExistEventFreqDB = errors.New("error trying to delete non existing event freq %s")
+	ErrInvalidInterval        = errors.New("invalid interval %s, must be minute, hour, day, week, month or year")
+	ErrInvalidRange           = errors.New("the end of the range must not be before its start")
+	ErrTooManyPoints          = errors.New("the range has more than %d intervals")
+	ErrInvalidValueInterval   = errors.New("invalid interval %s, must be hour, day, week, month or year")
+	ErrDeleteOccurrenceDB     = errors.New("error deleting event in event occurrence db: %s")
+	ErrDeleteRollupDB         = errors.New("error deleting event in event rollup db: %s")
+	ErrDeleteUniqueDB         = errors.New("error deleting event in event unique db: %s")
+	ErrDeleteValueDB          = errors.New("error deleting event in event value db: %s")
+	ErrUnknownStorage         = errors.New("unknown storage backend %s")
+	ErrMigrationFileName      = errors.New("invalid migration file name %s")
+	ErrMigration              = errors.New("error running migration %d (%s): %s")
+	ErrRetentionNotFound      = errors.New("no retention set for %s")
+	ErrDuplicateRequest       = errors.New("the request was already recorded")
+	ErrIdempotencyKeyReused   = errors.New("the idempotency key was already used for a different request")
+	ErrAPIKeyNotFound         = errors.New("api key %d not found")
+	ErrInvalidAPIKey          = errors.New("wrong auth apiKey")
+	ErrMissingScope           = errors.New("the api key lacks the %s scope")
+	ErrInvalidScope           = errors.New("invalid scope %s, must be ingest, read or admin")
+	ErrPruneEvent             = errors.New("error pruning expired occurrences of event %s: %s")
+	ErrRateLimited            = errors.New("rate limit of the api key exceeded")
+	ErrQuotaExceeded          = errors.New("daily ingestion quota of %d occurrences of the api key exceeded")
+	ErrInvalidProject         = errors.New("invalid project %s, must have 1 to 64 lowercase letters, digits, - or _")
+	ErrProjectForbidden       = errors.New("the api key can't access the project %s")
+	ErrInvalidEventPattern    = errors.New("invalid event name pattern %s")
+	ErrAlertRuleNotFound      = errors.New("alert rule %d not found")
+	ErrAlertRuleExists        = errors.New("an alert rule named %s already exists")
+	ErrNotifyWebhook          = errors.New("error notifying the webhook of alert rule %d: %s")
+	ErrBatchTooLarge          = errors.New("the batch has more than %d items")
+	ErrLimitsForbidden        = errors.New("only the admin keys of the default project can set the limits of the keys")
+	ErrOwnLimits              = errors.New("an api key can't change its own limits")
+	ErrInvalidWebhook         = errors.New("invalid webhook url %s, must be an https url")
+	ErrPrivateWebhook         = errors.New("the webhook address %s is private, loopback or link-local")
+	ErrAlertQueueFull         = errors.New("the queue of the alert notifications is full")
 )
 
@@ -1,17 +1,25 @@
 package model
 
+import "time"
+
 type Event struct {
-	ID    uint64 `json:"-"`
-	Name  string `json:"event"`
-	Count uint64 `json:"count"`
-	Date  string `json:"date"`
+	ID         uint64            `json:"-"`
+	Name       string            `json:"event"`
+	Count      uint64            `json:"count"`
+	Date       string            `json:"date"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Uniques    *uint64           `json:"uniques,omitempty"`
 }
 
+// WeekdayCount is indexed by time.Weekday, and WeekdayHourCount by weekday and then hour.
 type EventFreq struct {
-	ID         uint64     `json:"-"`
-	Name       string     `json:"event"`
-	TotalCount uint64     `json:"count"`
-	HourCount  [24]uint64 `json:"hour_count"`
+	ID               uint64            `json:"-"`
+	Name             string            `json:"event"`
+	TotalCount       uint64            `json:"count"`
+	HourCount        [24]uint64        `json:"hour_count"`
+	WeekdayCount     [7]uint64         `json:"weekday_count"`
+	WeekdayHourCount [7][24]uint64     `json:"weekday_hour_count"`
+	Properties       map[string]string `json:"properties,omitempty"`
 }
 
 type EventHistory struct {
@@ -21,7 +29,318 @@ type EventHistory struct {
 }
 
 type EventBody struct {
-	Count uint64 `json:"count,omitempty"`
-	Date  string `json:"date,omitempty"`
+	ID         string            `json:"id,omitempty"`
+	Count      uint64            `json:"count,omitempty"`
+	Date       string            `json:"date,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	UserID     string            `json:"user_id,omitempty"`
+	Value      *float64          `json:"value,omitempty"`
+}
+
+// Value is the numeric value of each of the occurrences, and is nil when they have none.
+type EventOccurrence struct {
+	Name       string
+	Count      uint64
+	Date       time.Time
+	Properties map[string]string
+	UserID     string
+	Value      *float64
+}
+
+type StreamedEvent struct {
+	Name       string            `json:"event"`
+	Count      uint64            `json:"count"`
+	Date       string            `json:"date"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type LiveCounter struct {
+	Name      string `json:"event"`
+	Delta     uint64 `json:"delta"`
+	HourCount uint64 `json:"hour_count"`
+}
+
+type LiveCountersMessage struct {
+	Type     string        `json:"type"`
+	Time     string        `json:"time"`
+	Hour     string        `json:"hour"`
+	Counters []LiveCounter `json:"counters"`
+	Dropped  uint64        `json:"dropped,omitempty"`
+}
+
+type LiveSubscription struct {
+	Subscribe   []string `json:"subscribe,omitempty"`
+	Unsubscribe []string `json:"unsubscribe,omitempty"`
+}
+
+// RequestHash tells the retries apart from other requests that happen to use the same key.
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+	CreatedAt   time.Time
+}
+
+type EventBatchItem struct {
+	Name       string            `json:"event"`
+	Count      uint64            `json:"count,omitempty"`
+	Date       string            `json:"date,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	UserID     string            `json:"user_id,omitempty"`
+	Value      *float64          `json:"value,omitempty"`
+}
+
+type EventBatchReport struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Results []EventBatchResult `json:"results"`
+}
+
+type EventBatchResult struct {
+	Index  int    `json:"index"`
+	Name   string `json:"event"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type EventPropertyCount struct {
+	Name       string
+	Date       string
+	Hour       uint64
+	Properties map[string]string
+	Count      uint64
+}
+
+type PropertyQuery struct {
+	GroupBy []string
+	Where   map[string]string
+}
+
+func (q PropertyQuery) IsEmpty() bool {
+	return len(q.GroupBy) == 0 && len(q.Where) == 0
+}
+
+type EventTimeCount struct {
+	Name  string
+	Time  time.Time
+	Count uint64
+}
+
+type EventUserTime struct {
+	Name   string
+	UserID string
+	Time   time.Time
+}
+
+type EventFunnel struct {
+	Start string            `json:"start_date"`
+	End   string            `json:"end_date"`
+	Steps []EventFunnelStep `json:"steps"`
+}
+
+// Conversion is the share of the users of the previous step, and TotalConversion of the first step.
+type EventFunnelStep struct {
+	Name            string  `json:"event"`
+	Users           uint64  `json:"users"`
+	Conversion      float64 `json:"conversion"`
+	TotalConversion float64 `json:"total_conversion"`
+}
+
+type EventUniqueRegister struct {
+	Name     string
+	Date     string
+	Register uint16
+	Rank     uint8
+}
+
+type EventUniques struct {
+	Name     string              `json:"event"`
+	Interval string              `json:"interval"`
+	Start    string              `json:"start_date"`
+	End      string              `json:"end_date"`
+	Uniques  uint64              `json:"uniques"`
+	Points   []EventUniquesPoint `json:"points"`
+}
+
+type EventUniquesPoint struct {
+	Date    string `json:"date"`
+	Uniques uint64 `json:"uniques"`
 }
 
+type EventValueStats struct {
+	Name  string
+	Time  time.Time
+	Count uint64
+	Total float64
+	Min   float64
+	Max   float64
+}
+
+type EventValueBucket struct {
+	Name   string
+	Time   time.Time
+	Bucket int
+	Count  uint64
+}
+
+type EventValues struct {
+	Name     string             `json:"event"`
+	Interval string             `json:"interval"`
+	Start    string             `json:"start"`
+	End      string             `json:"end"`
+	Summary  EventValueSummary  `json:"summary"`
+	Points   []EventValuesPoint `json:"points"`
+}
+
+type EventValuesPoint struct {
+	Time string `json:"time"`
+	EventValueSummary
+}
+
+type EventValueSummary struct {
+	Count       uint64             `json:"count"`
+	Sum         float64            `json:"sum"`
+	Min         *float64           `json:"min,omitempty"`
+	Max         *float64           `json:"max,omitempty"`
+	Mean        *float64           `json:"mean,omitempty"`
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+}
+
+type EventSeries struct {
+	Name     string             `json:"event"`
+	Interval string             `json:"interval"`
+	Start    string             `json:"start"`
+	End      string             `json:"end"`
+	Points   []EventSeriesPoint `json:"points"`
+}
+
+type EventSeriesPoint struct {
+	Time  string `json:"time"`
+	Count uint64 `json:"count"`
+}
+
+type EventAnomalies struct {
+	Name      string         `json:"event"`
+	Start     string         `json:"start"`
+	End       string         `json:"end"`
+	Weeks     int            `json:"weeks"`
+	Threshold float64        `json:"threshold"`
+	Anomalies []EventAnomaly `json:"anomalies"`
+}
+
+// Score is negative when the count is below the expected one, and Baseline has the last week first.
+type EventAnomaly struct {
+	Time     string   `json:"time"`
+	Actual   uint64   `json:"actual"`
+	Expected float64  `json:"expected"`
+	Lower    float64  `json:"lower"`
+	Upper    float64  `json:"upper"`
+	Score    float64  `json:"score"`
+	Baseline []uint64 `json:"baseline"`
+}
+
+// An empty Name is the global retention.
+type EventRetention struct {
+	Name string `json:"event"`
+	Days uint64 `json:"days"`
+}
+
+type RetentionSettings struct {
+	Days   uint64           `json:"days,omitempty"`
+	Events []EventRetention `json:"events"`
+}
+
+type RetentionBody struct {
+	Days uint64 `json:"days"`
+}
+
+type APIKey struct {
+	ID        uint64   `json:"id"`
+	Project   string   `json:"project"`
+	Name      string   `json:"name"`
+	Prefix    string   `json:"prefix"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt string   `json:"created_at"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+	RevokedAt string   `json:"revoked_at,omitempty"`
+	APIKeyLimits
+}
+
+// Zero limits fall back to the defaults of the app.
+type APIKeyLimits struct {
+	RateLimit  float64 `json:"rate_limit,omitempty"`
+	Burst      uint64  `json:"burst,omitempty"`
+	DailyQuota uint64  `json:"daily_quota,omitempty"`
+}
+
+// A zero rate limit or daily quota means there is no limit.
+type APIKeyUsage struct {
+	ID               uint64  `json:"id"`
+	Name             string  `json:"name"`
+	Day              string  `json:"day"`
+	Requests         uint64  `json:"requests"`
+	RejectedRequests uint64  `json:"rejected_requests"`
+	Ingested         uint64  `json:"ingested"`
+	AvailableTokens  float64 `json:"available_tokens"`
+	APIKeyLimits
+}
+
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyBody struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+	APIKeyLimitsBody
+}
+
+// Zero is rejected, since it could be read both as no limit and as no requests.
+type APIKeyLimitsBody struct {
+	RateLimit  *float64 `json:"rate_limit,omitempty"`
+	Burst      *uint64  `json:"burst,omitempty"`
+	DailyQuota *uint64  `json:"daily_quota,omitempty"`
+}
+
+// The secret signs the webhook notifications, and is never returned.
+type AlertRule struct {
+	ID            uint64 `json:"id"`
+	Project       string `json:"project"`
+	Name          string `json:"name"`
+	Event         string `json:"event"`
+	Condition     string `json:"condition"`
+	Threshold     uint64 `json:"threshold"`
+	WindowMinutes uint64 `json:"window_minutes"`
+	WebhookURL    string `json:"webhook_url"`
+	Secret        string `json:"-"`
+	State         string `json:"state"`
+	Value         uint64 `json:"value"`
+	ChangedAt     string `json:"changed_at,omitempty"`
+	EvaluatedAt   string `json:"evaluated_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// A nil Secret keeps the current one on updates.
+type AlertRuleBody struct {
+	Name          string  `json:"name"`
+	Event         string  `json:"event"`
+	Condition     string  `json:"condition"`
+	Threshold     uint64  `json:"threshold"`
+	WindowMinutes uint64  `json:"window_minutes"`
+	WebhookURL    string  `json:"webhook_url"`
+	Secret        *string `json:"secret,omitempty"`
+}
+
+type AlertNotification struct {
+	Project       string `json:"project"`
+	RuleID        uint64 `json:"rule_id"`
+	Rule          string `json:"rule"`
+	Event         string `json:"event"`
+	Condition     string `json:"condition"`
+	Threshold     uint64 `json:"threshold"`
+	WindowMinutes uint64 `json:"window_minutes"`
+	State         string `json:"state"`
+	Value         uint64 `json:"value"`
+	Time          string `json:"time"`
+}
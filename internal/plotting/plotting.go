@@ -3,10 +3,13 @@ package plotting
 import (
 	"fmt"
 	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 	"io"
+	"strconv"
+	"time"
 )
 
 func PlotHistogram(values plotter.Values, name string) io.WriterTo {
@@ -39,3 +42,44 @@ func PlotHistogram(values plotter.Values, name string) io.WriterTo {
 	return writer
 }
 
+// weekdayHourGrid is the plotter.GridXYZ of a weekday by hour matrix, with the hours as columns
+// and the weekdays (Sunday first) as rows.
+type weekdayHourGrid [7][24]float64
+
+func (g weekdayHourGrid) Dims() (c, r int)   { return 24, 7 }
+func (g weekdayHourGrid) Z(c, r int) float64 { return g[r][c] }
+func (g weekdayHourGrid) X(c int) float64    { return float64(c) }
+func (g weekdayHourGrid) Y(r int) float64    { return float64(r) }
+
+func PlotHeatmap(values [7][24]float64, name string) io.WriterTo {
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("Frequency of %s by weekday and hour (%% of occurence)", name)
+
+	p.X.Label.Text = "Hour"
+	var hourTicks []plot.Tick
+	for hour := 0; hour < 24; hour++ {
+		hourTicks = append(hourTicks, plot.Tick{Value: float64(hour), Label: strconv.Itoa(hour)})
+	}
+	p.X.Tick.Marker = plot.ConstantTicks(hourTicks)
+
+	var weekdayTicks []plot.Tick
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		weekdayTicks = append(weekdayTicks, plot.Tick{Value: float64(weekday), Label: weekday.String()[:3]})
+	}
+	p.Y.Tick.Marker = plot.ConstantTicks(weekdayTicks)
+
+	heatMap := plotter.NewHeatMap(weekdayHourGrid(values), palette.Heat(12, 1))
+	// The palette is scaled between the minimum and the maximum, which can't be equal.
+	if heatMap.Max == heatMap.Min {
+		heatMap.Max = heatMap.Min + 1
+	}
+
+	p.Add(heatMap)
+
+	writer, err := p.WriterTo(8*vg.Inch, 3*vg.Inch, "png")
+	if err != nil {
+		panic(err)
+	}
+
+	return writer
+}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"errors"
+	"eventTracker/internal/model"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter keeps a token bucket and the usage of the current UTC day of each API key, in memory.
+type Limiter struct {
+	defaults model.APIKeyLimits
+
+	mu   sync.Mutex
+	keys map[uint64]*keyState
+}
+
+type keyState struct {
+	tokens    float64
+	updatedAt time.Time
+
+	day              string
+	requests         uint64
+	rejectedRequests uint64
+	ingested         uint64
+}
+
+// A zero default rate limit or daily quota means no limit.
+func NewLimiter(defaults model.APIKeyLimits) *Limiter {
+	return &Limiter{defaults: defaults, keys: map[uint64]*keyState{}}
+}
+
+// A rate limit without a burst allows a second worth of requests at once.
+func (l *Limiter) Limits(key model.APIKey) model.APIKeyLimits {
+	limits := key.APIKeyLimits
+	if limits.RateLimit == 0 {
+		limits.RateLimit = l.defaults.RateLimit
+	}
+	if limits.Burst == 0 {
+		limits.Burst = l.defaults.Burst
+	}
+	if limits.Burst == 0 {
+		limits.Burst = uint64(math.Max(1, math.Ceil(limits.RateLimit)))
+	}
+	if limits.DailyQuota == 0 {
+		limits.DailyQuota = l.defaults.DailyQuota
+	}
+
+	return limits
+}
+
+func (l *Limiter) Allow(key model.APIKey, now time.Time) (retryAfter time.Duration, err error) {
+	limits := l.Limits(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.state(key.ID, limits, now)
+	if limits.RateLimit == 0 {
+		state.requests++
+		return 0, nil
+	}
+
+	if state.tokens < 1 {
+		state.rejectedRequests++
+		return time.Duration((1 - state.tokens) / limits.RateLimit * float64(time.Second)), model.ErrRateLimited
+	}
+
+	state.tokens--
+	state.requests++
+
+	return 0, nil
+}
+
+// ReserveIngestion counts nothing when the occurrences don't fit in what is left of the quota.
+func (l *Limiter) ReserveIngestion(key model.APIKey, occurrences uint64, now time.Time) (retryAfter time.Duration, err error) {
+	limits := l.Limits(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.state(key.ID, limits, now)
+	if limits.DailyQuota != 0 && (occurrences > limits.DailyQuota || state.ingested > limits.DailyQuota-occurrences) {
+		utc := now.UTC()
+		midnight := time.Date(utc.Year(), utc.Month(), utc.Day()+1, 0, 0, 0, 0, time.UTC)
+		return midnight.Sub(utc), errors.New(fmt.Sprintf(model.ErrQuotaExceeded.Error(), limits.DailyQuota))
+	}
+
+	state.ingested += occurrences
+
+	return 0, nil
+}
+
+func (l *Limiter) ReleaseIngestion(key model.APIKey, occurrences uint64, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.keys[key.ID]
+	if !ok || state.day != now.UTC().Format("2006-01-02") {
+		return
+	}
+
+	if occurrences > state.ingested {
+		occurrences = state.ingested
+	}
+	state.ingested -= occurrences
+}
+
+func (l *Limiter) Usage(key model.APIKey, now time.Time) model.APIKeyUsage {
+	limits := l.Limits(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.state(key.ID, limits, now)
+
+	return model.APIKeyUsage{
+		ID:               key.ID,
+		Name:             key.Name,
+		Day:              state.day,
+		Requests:         state.requests,
+		RejectedRequests: state.rejectedRequests,
+		Ingested:         state.ingested,
+		AvailableTokens:  math.Floor(state.tokens),
+		APIKeyLimits:     limits,
+	}
+}
+
+// The caller of state must hold l.mu.
+func (l *Limiter) state(ID uint64, limits model.APIKeyLimits, now time.Time) *keyState {
+	day := now.UTC().Format("2006-01-02")
+
+	state, ok := l.keys[ID]
+	if !ok {
+		state = &keyState{tokens: float64(limits.Burst), updatedAt: now, day: day}
+		l.keys[ID] = state
+	}
+
+	if elapsed := now.Sub(state.updatedAt); elapsed > 0 {
+		state.tokens += elapsed.Seconds() * limits.RateLimit
+		state.updatedAt = now
+	}
+	if state.tokens > float64(limits.Burst) {
+		state.tokens = float64(limits.Burst)
+	}
+
+	if state.day != day {
+		state.day = day
+		state.requests = 0
+		state.rejectedRequests = 0
+		state.ingested = 0
+	}
+
+	return state
+}
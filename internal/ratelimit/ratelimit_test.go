@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"errors"
+	"eventTracker/internal/model"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	start := time.Date(2021, 1, 1, 23, 59, 0, 0, time.UTC)
+
+	// A step either takes a token (occurrences is 0) or reserves occurrences of the daily quota, at an
+	// offset from start.
+	type step struct {
+		at          time.Duration
+		occurrences uint64
+		limited     bool
+		wantRetry   time.Duration
+	}
+
+	for _, test := range []struct {
+		name     string
+		defaults model.APIKeyLimits
+		limits   model.APIKeyLimits
+		steps    []step
+	}{
+		{
+			name:     "burst then refill",
+			defaults: model.APIKeyLimits{RateLimit: 2, Burst: 3},
+			steps: []step{
+				{at: 0},
+				{at: 0},
+				{at: 0},
+				{at: 0, limited: true, wantRetry: 500 * time.Millisecond},
+				{at: 250 * time.Millisecond, limited: true, wantRetry: 250 * time.Millisecond},
+				{at: 500 * time.Millisecond},
+				{at: 500 * time.Millisecond, limited: true, wantRetry: 500 * time.Millisecond},
+			},
+		},
+		{
+			name:     "refill capped at the burst",
+			defaults: model.APIKeyLimits{RateLimit: 10, Burst: 2},
+			steps: []step{
+				{at: 0},
+				{at: 0},
+				{at: time.Minute},
+				{at: time.Minute},
+				{at: time.Minute, limited: true, wantRetry: 100 * time.Millisecond},
+			},
+		},
+		{
+			name:     "burst defaults to a second of requests",
+			defaults: model.APIKeyLimits{RateLimit: 1.5},
+			steps: []step{
+				{at: 0},
+				{at: 0},
+				{at: 0, limited: true, wantRetry: 2 * time.Second / 3},
+			},
+		},
+		{
+			name: "no default limits",
+			steps: []step{
+				{at: 0},
+				{at: 0},
+				{at: 0, occurrences: 1 << 40},
+			},
+		},
+		{
+			name:     "key overrides the defaults",
+			defaults: model.APIKeyLimits{RateLimit: 100, Burst: 100, DailyQuota: 1000},
+			limits:   model.APIKeyLimits{RateLimit: 1, Burst: 1, DailyQuota: 5},
+			steps: []step{
+				{at: 0},
+				{at: 0, limited: true, wantRetry: time.Second},
+				{at: 0, occurrences: 5},
+				{at: 0, occurrences: 1, limited: true, wantRetry: time.Minute},
+			},
+		},
+		{
+			name:     "quota rolls over at midnight",
+			defaults: model.APIKeyLimits{DailyQuota: 10},
+			steps: []step{
+				{at: 0, occurrences: 8},
+				{at: 30 * time.Second, occurrences: 3, limited: true, wantRetry: 30 * time.Second},
+				{at: 30 * time.Second, occurrences: 2},
+				{at: 59 * time.Second, occurrences: 1, limited: true, wantRetry: time.Second},
+				{at: time.Minute, occurrences: 10},
+				{at: time.Minute, occurrences: 1, limited: true, wantRetry: 24 * time.Hour},
+			},
+		},
+		{
+			name:     "request above the quota",
+			defaults: model.APIKeyLimits{DailyQuota: 10},
+			steps: []step{
+				{at: 0, occurrences: 11, limited: true, wantRetry: time.Minute},
+				{at: 0, occurrences: 10},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := NewLimiter(test.defaults)
+			key := model.APIKey{ID: 1, APIKeyLimits: test.limits}
+
+			for i, step := range test.steps {
+				var (
+					retry time.Duration
+					err   error
+				)
+				if step.occurrences == 0 {
+					retry, err = limiter.Allow(key, start.Add(step.at))
+				} else {
+					retry, err = limiter.ReserveIngestion(key, step.occurrences, start.Add(step.at))
+				}
+
+				if limited := err != nil; limited != step.limited {
+					t.Fatalf("step %d was limited: %v (%v), want %v", i, limited, err, step.limited)
+				}
+				if step.occurrences == 0 && err != nil && !errors.Is(err, model.ErrRateLimited) {
+					t.Fatalf("step %d got %v, want %v", i, err, model.ErrRateLimited)
+				}
+				if diff := retry - step.wantRetry; diff < -time.Millisecond || diff > time.Millisecond {
+					t.Fatalf("step %d retries after %v, want %v", i, retry, step.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(model.APIKeyLimits{RateLimit: 1, Burst: 1, DailyQuota: 3})
+	first, second := model.APIKey{ID: 1}, model.APIKey{ID: 2, APIKeyLimits: model.APIKeyLimits{Burst: 2}}
+
+	if _, err := limiter.Allow(first, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.Allow(first, now); err == nil {
+		t.Fatal("the first key took a second token")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := limiter.Allow(second, now); err != nil {
+			t.Fatalf("the second key was limited on request %d: %v", i, err)
+		}
+	}
+
+	if _, err := limiter.ReserveIngestion(first, 3, now); err != nil {
+		t.Fatal(err)
+	}
+	limiter.ReleaseIngestion(first, 2, now)
+	if _, err := limiter.ReserveIngestion(first, 2, now); err != nil {
+		t.Fatalf("the released occurrences weren't given back: %v", err)
+	}
+
+	usage := limiter.Usage(second, now)
+	if usage.Requests != 2 || usage.Ingested != 0 || usage.Burst != 2 || usage.RateLimit != 1 || usage.DailyQuota != 3 {
+		t.Fatalf("usage of the second key = %+v", usage)
+	}
+	if usage = limiter.Usage(first, now); usage.Requests != 1 || usage.RejectedRequests != 1 || usage.Ingested != 3 {
+		t.Fatalf("usage of the first key = %+v", usage)
+	}
+}
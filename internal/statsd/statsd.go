@@ -0,0 +1,234 @@
+package statsd
+
+import (
+	"eventTracker/internal/db"
+	"eventTracker/internal/event"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxPacketSize = 65535
+
+// The counters left with less than an occurrence are dropped after maxIdleFlushes, and the failing ones after maxFlushRetries.
+const (
+	maxIdleFlushes  = 10
+	maxFlushRetries = 5
+)
+
+// maxCount is the largest count that float64 still counts one by one.
+const maxCount = 1 << 53
+
+// Listener records the StatsD counters as events every flush interval, and discards the other metrics.
+type Listener struct {
+	EventService       event.EventServiceI
+	EventIngestHandler db.EventIngestHandler
+	FlushInterval      time.Duration
+
+	mu        sync.Mutex
+	counts    map[string]*counter
+	discarded uint64
+}
+
+type counter struct {
+	count    float64
+	idle     int
+	failures int
+}
+
+func NewListener(eventService event.EventServiceI, eventIngestHandler db.EventIngestHandler, flushInterval time.Duration) *Listener {
+	return &Listener{
+		EventService:       eventService,
+		EventIngestHandler: eventIngestHandler,
+		FlushInterval:      flushInterval,
+		counts:             map[string]*counter{},
+	}
+}
+
+func (l *Listener) ListenAndServe(addr string, stop <-chan struct{}) error {
+	conn, e := net.ListenPacket("udp", addr)
+	if e != nil {
+		return e
+	}
+
+	return l.Serve(conn, stop)
+}
+
+func (l *Listener) Serve(conn net.PacketConn, stop <-chan struct{}) error {
+	readErr := make(chan error, 1)
+	go func() {
+		packet := make([]byte, maxPacketSize)
+		for {
+			n, _, e := conn.ReadFrom(packet)
+			if e != nil {
+				readErr <- e
+				return
+			}
+
+			l.Add(packet[:n])
+		}
+	}()
+
+	ticker := time.NewTicker(l.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.flush(time.Now())
+		case e := <-readErr:
+			l.flush(time.Now())
+			return e
+		case <-stop:
+			e := conn.Close()
+			<-readErr
+			l.flush(time.Now())
+			return e
+		}
+	}
+}
+
+func (l *Listener) Add(packet []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, line := range strings.Split(string(packet), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, count, ok := parseCounter(line)
+		if !ok {
+			l.discarded++
+			continue
+		}
+
+		c, ok := l.counts[name]
+		if !ok {
+			c = &counter{}
+			l.counts[name] = c
+		}
+		if count > maxCount-c.count {
+			c.count = maxCount
+			l.discarded++
+			continue
+		}
+		c.count += count
+	}
+}
+
+// parseCounter scales the sampled counters up by their rate, and ignores the DogStatsD tags.
+func parseCounter(line string) (name string, count float64, ok bool) {
+	name, rest, found := strings.Cut(line, ":")
+	if !found || name == "" {
+		return "", 0, false
+	}
+
+	fields := strings.Split(rest, "|")
+	if len(fields) < 2 || fields[1] != "c" {
+		return "", 0, false
+	}
+
+	count, e := strconv.ParseFloat(fields[0], 64)
+	if e != nil || count < 0 || count > maxCount || math.IsNaN(count) {
+		return "", 0, false
+	}
+
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			rate, e := strconv.ParseFloat(field[1:], 64)
+			if e != nil || rate <= 0 || rate > 1 {
+				return "", 0, false
+			}
+			count /= rate
+			if count > maxCount {
+				return "", 0, false
+			}
+		case strings.HasPrefix(field, "#"):
+		default:
+			return "", 0, false
+		}
+	}
+
+	return name, count, true
+}
+
+// Flush keeps the fractions of occurrences left by the sample rates, and the failing counters, for the next flush.
+func (l *Listener) Flush(now time.Time) (err error) {
+	l.mu.Lock()
+	counts := l.counts
+	l.counts = map[string]*counter{}
+	l.mu.Unlock()
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	kept := map[string]*counter{}
+	for _, name := range names {
+		c := counts[name]
+		occurrences := math.Floor(c.count)
+		if occurrences < 1 {
+			c.idle++
+			if c.idle < maxIdleFlushes {
+				kept[name] = c
+			}
+			continue
+		}
+
+		e := l.EventService.CreateEvent(l.EventIngestHandler, name, uint64(occurrences), now, nil, "", nil)
+		if e != nil {
+			err = e
+			c.failures++
+			if c.failures > maxFlushRetries {
+				println(fmt.Sprintf("Dropped %v StatsD occurrences of %s after %d failed flushes", occurrences, name, c.failures))
+				continue
+			}
+			kept[name] = c
+			continue
+		}
+
+		if fraction := c.count - occurrences; fraction > 0 {
+			kept[name] = &counter{count: fraction}
+		}
+	}
+
+	// The counters sent during the flush are in the new map.
+	l.mu.Lock()
+	for name, c := range kept {
+		if current, ok := l.counts[name]; ok {
+			current.count += c.count
+			current.failures = c.failures
+			continue
+		}
+		l.counts[name] = c
+	}
+	l.mu.Unlock()
+
+	return err
+}
+
+func (l *Listener) flush(now time.Time) {
+	l.mu.Lock()
+	discarded := l.discarded
+	l.discarded = 0
+	l.mu.Unlock()
+
+	if discarded > 0 {
+		println(fmt.Sprintf("Discarded %d StatsD lines that aren't counters or overflow their counter", discarded))
+	}
+
+	e := l.Flush(now)
+	if e != nil {
+		println(fmt.Sprintf("Error recording the StatsD counters: %s", e.Error()))
+	}
+}
@@ -0,0 +1,140 @@
+package statsd
+
+import (
+	"errors"
+	"eventTracker/internal/db"
+	"eventTracker/internal/event"
+	"eventTracker/internal/model"
+	"testing"
+	"time"
+)
+
+// recordingIngestHandler keeps the occurrences ingested through it, and fails while failing is set.
+type recordingIngestHandler struct {
+	occurrences *[]model.EventOccurrence
+	failing     *bool
+}
+
+func (h recordingIngestHandler) IngestEvents(occurrences []model.EventOccurrence) (err error) {
+	if *h.failing {
+		return errors.New("the storage is down")
+	}
+
+	*h.occurrences = append(*h.occurrences, occurrences...)
+	return nil
+}
+
+func (h recordingIngestHandler) IngestEventsOnce(key model.IdempotencyKey, window time.Duration, occurrences []model.EventOccurrence) (err error) {
+	return h.IngestEvents(occurrences)
+}
+
+func (h recordingIngestHandler) ForProject(project string) db.EventIngestHandler {
+	return h
+}
+
+func newTestListener() (*Listener, *[]model.EventOccurrence, *bool) {
+	occurrences, failing := &[]model.EventOccurrence{}, new(bool)
+	return NewListener(event.EventService{}, recordingIngestHandler{occurrences: occurrences, failing: failing}, time.Second), occurrences, failing
+}
+
+func TestFlushDropsIdleRemainders(t *testing.T) {
+	l, occurrences, _ := newTestListener()
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// A sampled counter of 1 at a rate of 0.3 is 3.33 occurrences.
+	l.Add([]byte("login:1|c|@0.3"))
+	if err := l.Flush(now); err != nil {
+		t.Fatal(err)
+	}
+	if len(*occurrences) != 1 || (*occurrences)[0].Count != 3 {
+		t.Fatalf("recorded %v, want 3 logins", *occurrences)
+	}
+
+	for i := 1; i < maxIdleFlushes; i++ {
+		if err := l.Flush(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c, ok := l.counts["login"]; !ok || c.idle != maxIdleFlushes-1 {
+		t.Fatalf("the login remainder is %+v after %d idle flushes, want it kept", c, maxIdleFlushes-1)
+	}
+
+	if err := l.Flush(now); err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := l.counts["login"]; ok {
+		t.Fatalf("the login remainder is still %+v after %d idle flushes", c, maxIdleFlushes)
+	}
+}
+
+func TestFlushKeepsSentRemainders(t *testing.T) {
+	l, occurrences, _ := newTestListener()
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// A quarter of an occurrence per flush is never idle, and adds up to an occurrence every four flushes.
+	for i := 0; i < 4*maxIdleFlushes; i++ {
+		l.Add([]byte("login:0.25|c"))
+		if err := l.Flush(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var recorded uint64
+	for _, occurrence := range *occurrences {
+		recorded += occurrence.Count
+	}
+	if recorded != maxIdleFlushes {
+		t.Fatalf("recorded %d logins, want %d", recorded, maxIdleFlushes)
+	}
+}
+
+func TestFlushRetriesFailedCounters(t *testing.T) {
+	l, occurrences, failing := newTestListener()
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	*failing = true
+	l.Add([]byte("login:2|c"))
+	for i := 0; i < maxFlushRetries; i++ {
+		if err := l.Flush(now); err == nil {
+			t.Fatal("the flush didn't fail with the storage down")
+		}
+	}
+	if c, ok := l.counts["login"]; !ok || c.count != 2 || c.failures != maxFlushRetries {
+		t.Fatalf("the login counter is %+v after %d failed flushes, want it kept for a retry", c, maxFlushRetries)
+	}
+
+	// The counters sent in the meantime are retried with the failed ones.
+	l.Add([]byte("login:1|c"))
+	*failing = false
+	if err := l.Flush(now); err != nil {
+		t.Fatal(err)
+	}
+	if len(*occurrences) != 1 || (*occurrences)[0].Count != 3 || len(l.counts) != 0 {
+		t.Fatalf("recorded %v with %v left, want 3 logins", *occurrences, l.counts)
+	}
+
+	*failing = true
+	l.Add([]byte("logout:1|c"))
+	for i := 0; i <= maxFlushRetries; i++ {
+		_ = l.Flush(now)
+	}
+	if c, ok := l.counts["logout"]; ok {
+		t.Fatalf("the logout counter is still %+v after %d failed flushes", c, maxFlushRetries+1)
+	}
+}
+
+func TestAddCapsCounters(t *testing.T) {
+	l, occurrences, _ := newTestListener()
+
+	l.Add([]byte("huge:1e300|c\nsampled:1e10|c|@1e-10\ninf:+Inf|c\nlogin:9007199254740990|c\nlogin:2|c\nlogin:1|c"))
+	if l.discarded != 4 {
+		t.Fatalf("discarded %d lines, want the 3 above the cap and the one overflowing login", l.discarded)
+	}
+
+	if err := l.Flush(time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if len(*occurrences) != 1 || (*occurrences)[0].Name != "login" || (*occurrences)[0].Count != maxCount {
+		t.Fatalf("recorded %v, want %d logins", *occurrences, uint64(maxCount))
+	}
+}